@@ -41,6 +41,10 @@ const (
 	ApplyActionID = "APPLY"
 	// DestroyActionID identifies the action of destroying an existing environment
 	DestroyActionID = "DESTROY"
+	// ConnectivityActionID identifies the action of checking the SSH access to the hosts of an environment
+	ConnectivityActionID = "CONNECTIVITY"
+	// ExecActionID identifies the action of running a command on the hosts of an environment
+	ExecActionID = "EXEC"
 )
 
 // String returns the string representation of the action id
@@ -55,6 +59,8 @@ func All() []Action {
 	r = append(r, checkAction)
 	r = append(r, dumpAction)
 	r = append(r, validateAction)
+	r = append(r, connectivityAction)
+	r = append(r, execAction)
 	return r
 }
 
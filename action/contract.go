@@ -0,0 +1,220 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+)
+
+type (
+	// contractFailure details a playbook missing from a component for the
+	// role it plays into the environment
+	contractFailure struct {
+		// The component expected to hold the playbook
+		Component string
+		// The role played by the component: Provider, Orchestrator, Stack or Task
+		Role string
+		// The name of the environment part playing the role
+		Name string
+		// The missing playbook
+		Playbook string
+		// The default playbook overridden by the missing one, if any
+		Overrides string `json:",omitempty"`
+		// The component which could have provided the playbook instead, if any
+		Fallback string `json:",omitempty"`
+	}
+
+	// contractHolder is an environment part referencing the component
+	// which must fulfill its contract
+	contractHolder interface {
+		componentizer.ComponentRef
+		model.Describable
+	}
+)
+
+func (f contractFailure) Error() string {
+	msg := fmt.Sprintf("component %s does not contain the playbook %s required by %s %s", f.Component, f.Playbook, strings.ToLower(f.Role), f.Name)
+	if f.Overrides != "" {
+		msg = msg + fmt.Sprintf(" in place of %s", f.Overrides)
+	}
+	if f.Fallback != "" {
+		msg = msg + fmt.Sprintf(", neither does the orchestrator component %s", f.Fallback)
+	}
+	return msg
+}
+
+// checkContracts verifies, for each role played by a component, that the
+// component provides the playbooks which will be launched during the
+// environment lifecycle.
+//
+// Only the components locally available are verified, the others won't be
+// used by any action.
+func checkContracts(rC *RuntimeContext) ([]contractFailure, error) {
+	failures := make([]contractFailure, 0)
+	env := rC.environment
+
+	// Providers
+	pNames := make([]string, 0, len(env.Providers))
+	for name := range env.Providers {
+		pNames = append(pNames, name)
+	}
+	sort.Strings(pNames)
+	for _, name := range pNames {
+		p := env.Providers[name]
+		if !rC.cM.IsAvailable(p) {
+			continue
+		}
+		f, err := requirePlaybooks(rC, p, setupPlaybook, createPlaybook, destroyPlaybook)
+		if err != nil {
+			return failures, err
+		}
+		failures = append(failures, f...)
+	}
+
+	// Orchestrator
+	var uo componentizer.UsableComponent
+	if rC.cM.IsAvailable(env.Orchestrator) {
+		f, err := requirePlaybooks(rC, env.Orchestrator, setupPlaybook, installPlaybook)
+		if err != nil {
+			return failures, err
+		}
+		failures = append(failures, f...)
+
+		uo, err = rC.cM.Use(env.Orchestrator, rC.tplC)
+		if err != nil {
+			return failures, err
+		}
+		defer uo.Release()
+	}
+
+	// Stacks, falling back on the orchestrator for deploy and copy
+	for _, st := range env.Stacks.Sorted() {
+		if !rC.cM.IsAvailable(st) {
+			continue
+		}
+		ust, err := rC.cM.Use(st, rC.tplC)
+		if err != nil {
+			return failures, err
+		}
+		defer ust.Release()
+
+		required := make([]string, 0, 2)
+		if st.Playbook != "" {
+			// A custom playbook must be held by the stack itself
+			if ok, _ := ust.ContainsFile(st.Playbook); !ok {
+				failures = append(failures, contractFailure{
+					Component: ust.Id(),
+					Role:      st.DescType(),
+					Name:      st.DescName(),
+					Playbook:  st.Playbook,
+					Overrides: deployPlaybook,
+				})
+			}
+		} else {
+			required = append(required, deployPlaybook)
+		}
+		for _, cp := range st.Copies {
+			if cp.Path != "" {
+				required = append(required, copyPlaybook)
+				break
+			}
+		}
+		for _, playbook := range required {
+			if f, ko := requireWithFallback(rC, ust, uo, st, playbook); ko {
+				failures = append(failures, f)
+			}
+		}
+	}
+
+	// Global volumes content, falling back on the orchestrator for copy
+	for _, v := range env.Volumes.Sorted() {
+		for _, content := range v.Content {
+			if !rC.cM.IsAvailable(content) {
+				continue
+			}
+			uc, err := rC.cM.Use(content, rC.tplC)
+			if err != nil {
+				return failures, err
+			}
+			defer uc.Release()
+			if f, ko := requireWithFallback(rC, uc, uo, content, copyPlaybook); ko {
+				failures = append(failures, f)
+			}
+		}
+	}
+
+	// Tasks
+	tNames := make([]string, 0, len(env.Tasks))
+	for name := range env.Tasks {
+		tNames = append(tNames, name)
+	}
+	sort.Strings(tNames)
+	for _, name := range tNames {
+		t := env.Tasks[name]
+		if !rC.cM.IsAvailable(t) || t.Playbook == "" {
+			continue
+		}
+		f, err := requirePlaybooks(rC, t, t.Playbook)
+		if err != nil {
+			return failures, err
+		}
+		failures = append(failures, f...)
+	}
+
+	return failures, nil
+}
+
+// requireWithFallback returns a failure if neither the component nor the
+// orchestrator, when usable, contain the given playbook or the custom one
+// overriding it
+func requireWithFallback(rC *RuntimeContext, uc componentizer.UsableComponent, uo componentizer.UsableComponent, h model.Describable, playbook string) (contractFailure, bool) {
+	f := playbookFailure(rC, uc, h, playbook)
+	if ok, _ := uc.ContainsFile(f.Playbook); ok {
+		return f, false
+	}
+	if uo != nil {
+		if ok, _ := uo.ContainsFile(playbookOf(rC, uo, playbook)); ok {
+			return f, false
+		}
+		f.Fallback = uo.Id()
+	}
+	return f, true
+}
+
+// requirePlaybooks returns a failure for each of the given playbooks, or the
+// custom ones overriding them, not contained into the component referenced
+// by the holder
+func requirePlaybooks(rC *RuntimeContext, h contractHolder, playbooks ...string) ([]contractFailure, error) {
+	res := make([]contractFailure, 0)
+	uc, err := rC.cM.Use(h, rC.tplC)
+	if err != nil {
+		return res, err
+	}
+	defer uc.Release()
+
+	for _, playbook := range playbooks {
+		f := playbookFailure(rC, uc, h, playbook)
+		if ok, _ := uc.ContainsFile(f.Playbook); !ok {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+// playbookFailure returns the failure reported if the component doesn't
+// contain the playbook to launch in place of the given default one
+func playbookFailure(rC *RuntimeContext, uc componentizer.UsableComponent, h model.Describable, playbook string) contractFailure {
+	f := contractFailure{
+		Component: uc.Id(),
+		Role:      h.DescType(),
+		Name:      h.DescName(),
+		Playbook:  playbookOf(rC, uc, playbook),
+	}
+	if f.Playbook != playbook {
+		f.Overrides = playbook
+	}
+	return f
+}
@@ -0,0 +1,211 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckContracts(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("setup.yaml", "")
+	repProvider.WriteCommit("create.yaml", "")
+
+	repOrchestrator := tester.CreateDir("orchestrator")
+	repOrchestrator.WriteCommit("setup.yaml", "")
+	repOrchestrator.WriteCommit("install.yaml", "")
+	repOrchestrator.WriteCommit("copy.yaml", "")
+
+	repStack1 := tester.CreateDir("stack1")
+	repStack1.WriteCommit("deploy.yaml", "")
+
+	repStack2 := tester.CreateDir("stack2")
+	repStack2.WriteCommit("check.yaml", "")
+
+	repTask := tester.CreateDir("task")
+	repTask.WriteCommit("other.yaml", "")
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: contract
+ekara:
+  components:
+    provider:
+      repository: provider
+    orchestrator:
+      repository: orchestrator
+    stack1:
+      repository: stack1
+    stack2:
+      repository: stack2
+    task:
+      repository: task
+orchestrator:
+  component: orchestrator
+providers:
+  p1:
+    component: provider
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: p1
+stacks:
+  stack1:
+    component: stack1
+    copies:
+      data:
+        path: /data
+        sources:
+          - "*.txt"
+  stack2:
+    component: stack2
+tasks:
+  task1:
+    component: task
+    playbook: task.yaml
+`)
+
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+
+	rC := CreateRuntimeContext(util.CreateMockLaunchContext(false), tester.ComponentManager(), nil, env, tester.TemplateContext())
+	failures, err := checkContracts(rC)
+	assert.Nil(t, err)
+	if assert.Len(t, failures, 3) {
+		assert.Equal(t, contractFailure{Component: "provider", Role: "Provider", Name: "p1", Playbook: destroyPlaybook}, failures[0])
+		assert.Equal(t, contractFailure{Component: "stack2", Role: "Stack", Name: "stack2", Playbook: deployPlaybook, Fallback: "orchestrator"}, failures[1])
+		assert.Equal(t, contractFailure{Component: "task", Role: "Task", Name: "task1", Playbook: "task.yaml"}, failures[2])
+		assert.Equal(t, "component stack2 does not contain the playbook deploy.yaml required by stack stack2, neither does the orchestrator component orchestrator", failures[1].Error())
+	}
+}
+
+func TestCheckContractsVolumes(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("setup.yaml", "")
+	repProvider.WriteCommit("create.yaml", "")
+	repProvider.WriteCommit("destroy.yaml", "")
+
+	repOrchestrator := tester.CreateDir("orchestrator")
+	repOrchestrator.WriteCommit("setup.yaml", "")
+	repOrchestrator.WriteCommit("install.yaml", "")
+
+	repData := tester.CreateDir("data")
+	repData.WriteCommit("shared.txt", "")
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: contract
+ekara:
+  components:
+    provider:
+      repository: provider
+    orchestrator:
+      repository: orchestrator
+    data:
+      repository: data
+orchestrator:
+  component: orchestrator
+providers:
+  p1:
+    component: provider
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: p1
+    volumes:
+      - path: /var/lib/shared
+volumes:
+  /var/lib/shared:
+    content:
+      - component: data
+        path: shared.txt
+`)
+
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+
+	rC := CreateRuntimeContext(util.CreateMockLaunchContext(false), tester.ComponentManager(), nil, env, tester.TemplateContext())
+	failures, err := checkContracts(rC)
+	assert.Nil(t, err)
+	if assert.Len(t, failures, 1) {
+		assert.Equal(t, contractFailure{Component: "data", Role: "VolumeContent", Name: "/var/lib/shared:shared.txt", Playbook: copyPlaybook, Fallback: "orchestrator"}, failures[0])
+	}
+}
+
+func TestCheckContractsCustomPlaybooks(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("ekara.yaml", `
+ekara:
+  playbooks:
+    create: provision.yaml
+    destroy.yaml: decommission.yaml
+`)
+	repProvider.WriteCommit("setup.yaml", "")
+	repProvider.WriteCommit("create.yaml", "")
+	repProvider.WriteCommit("destroy.yaml", "")
+	repProvider.WriteCommit("provision.yaml", "")
+
+	repOrchestrator := tester.CreateDir("orchestrator")
+	repOrchestrator.WriteCommit("setup.yaml", "")
+	repOrchestrator.WriteCommit("install.yaml", "")
+
+	repStack := tester.CreateDir("stack")
+	repStack.WriteCommit("deploy.yaml", "")
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: contract
+ekara:
+  components:
+    provider:
+      repository: provider
+    orchestrator:
+      repository: orchestrator
+    stack:
+      repository: stack
+orchestrator:
+  component: orchestrator
+providers:
+  p1:
+    component: provider
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: p1
+stacks:
+  stack1:
+    component: stack
+    playbook: custom.yaml
+`)
+
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+
+	rC := CreateRuntimeContext(util.CreateMockLaunchContext(false), tester.ComponentManager(), nil, env, tester.TemplateContext())
+	assert.Equal(t, "provision.yaml", env.Platform.Playbook("provider", createPlaybook))
+	assert.Equal(t, "decommission.yaml", env.Platform.Playbook("provider", destroyPlaybook))
+	assert.Equal(t, setupPlaybook, env.Platform.Playbook("provider", setupPlaybook))
+	assert.Equal(t, "custom.yaml", env.Stacks["stack1"].Playbook)
+
+	failures, err := checkContracts(rC)
+	assert.Nil(t, err)
+	if assert.Len(t, failures, 2) {
+		assert.Equal(t, contractFailure{Component: "provider", Role: "Provider", Name: "p1", Playbook: "decommission.yaml", Overrides: destroyPlaybook}, failures[0])
+		assert.Equal(t, contractFailure{Component: "stack", Role: "Stack", Name: "stack1", Playbook: "custom.yaml", Overrides: deployPlaybook}, failures[1])
+		assert.Equal(t, "component provider does not contain the playbook decommission.yaml required by provider p1 in place of destroy.yaml", failures[0].Error())
+	}
+}
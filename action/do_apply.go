@@ -3,9 +3,13 @@ package action
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/GroupePSA/componentizer"
 	"github.com/ekara-platform/engine/ansible"
 	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
 	"github.com/ekara-platform/engine/util"
 )
 
@@ -66,6 +70,7 @@ var (
 			installHookAfter,
 
 			deployHookBefore,
+			volumeCopy,
 			stackCopy, // TODO merge into one deploy step with sub-function calls (so we can sort stacks only once)
 			stackCheck,
 			stackDeploy,
@@ -120,10 +125,11 @@ func providerSetup(rC *RuntimeContext) StepResults {
 		defer usable.Release()
 
 		// We launch the playbook
-		code, err := rC.aM.Play(usable, rC.tplC, setupPlaybook, exv)
+		playbook := playbookOf(rC, usable, setupPlaybook)
+		code, err := playLocally(rC, &sc, usable, playbook, exv)
 		if err != nil {
 			pfd := playBookFailureDetail{
-				Playbook:  setupPlaybook,
+				Playbook:  playbook,
 				Component: p.ComponentId(),
 				Code:      code,
 			}
@@ -198,6 +204,7 @@ func providerCreate(rC *RuntimeContext) StepResults {
 		bp.AddInterface("labels", n.Labels)
 		bp.AddNamedMap("params", p.Parameters())
 		bp.AddInterface("proxy", p.Proxy())
+		bp.AddInterface("volumes", n.Volumes.AsParams())
 
 		// Process hook : nodeset - create - before
 		runHookBefore(
@@ -208,42 +215,20 @@ func providerCreate(rC *RuntimeContext) StepResults {
 			NoCleanUpRequired,
 		)
 
-		// Node creation exchange folder
-		nodeCreateEf, ko := createChildExchangeFolder(rC.lC.Ef().Input, "create_"+n.Name, &sc)
-		if ko {
-			sCs.Add(sc)
-			return *sCs
-		}
-
-		if ko := saveBaseParams(bp, nodeCreateEf.Input, &sc); ko {
-			sCs.Add(sc)
-			return *sCs
-		}
-
-		// Prepare extra vars
-		exv := ansible.CreateExtraVars(nodeCreateEf.Input, nodeCreateEf.Output)
-
-		// Make the provider usable
-		usable, err := rC.cM.Use(p, rC.tplC)
-		if err != nil {
-			FailsOnCode(&sc, err, "An error occurred getting the usable provider", nil)
-			sCs.Add(sc)
-			return *sCs
-		}
-		defer usable.Release()
-
-		// Launch the playbook
-		code, err := rC.aM.Play(usable, rC.tplC, createPlaybook, exv)
-
-		if err != nil {
-			pfd := playBookFailureDetail{
-				Playbook:  createPlaybook,
-				Component: p.ComponentId(),
-				Code:      code,
+		// Launch the provider playbook, the hosts of a static provider already exist
+		if p.IsStatic() {
+			rC.lC.Log().Printf("Node set %s uses existing hosts, nothing to create", n.Name)
+		} else {
+			ko := providerPlay(rC, &sc, p, bp, "create_"+n.Name, createPlaybook)
+			// The nodes change, even partially, so the inventory must be generated
+			// again, before running the hooks
+			rC.aM.InvalidateInventory()
+			if ko {
+				sCs.Add(sc)
+				return *sCs
 			}
-			FailsOnPlaybook(&sc, err, "An error occurred executing the playbook", pfd)
-			sCs.Add(sc)
-			return *sCs
+			// The facts cached for the created hosts are outdated
+			invalidateFacts(rC, n.Name)
 		}
 
 		// Process hook : nodeset - create - after
@@ -317,6 +302,7 @@ func orchestratorSetup(rC *RuntimeContext) StepResults {
 	// Prepare parameters
 	bp := buildBaseParam(rC, "")
 	bp.AddNamedMap("params", o.Parameters())
+	bp.AddNamedMap("nodesets", orchestratorNodeSetsParams(rC.environment))
 	if ko := saveBaseParams(bp, setupOrchestratorEf.Input, &sc); ko {
 		sCs.Add(sc)
 		return *sCs
@@ -335,10 +321,11 @@ func orchestratorSetup(rC *RuntimeContext) StepResults {
 	defer usable.Release()
 
 	// We launch the playbook
-	code, err := rC.aM.Play(usable, rC.tplC, setupPlaybook, exv)
+	playbook := playbookOf(rC, usable, setupPlaybook)
+	code, err := playLocally(rC, &sc, usable, playbook, exv)
 	if err != nil {
 		pfd := playBookFailureDetail{
-			Playbook:  setupPlaybook,
+			Playbook:  playbook,
 			Component: o.ComponentId(),
 			Code:      code,
 		}
@@ -354,6 +341,24 @@ func orchestratorSetup(rC *RuntimeContext) StepResults {
 	return *sCs
 }
 
+// orchestratorNodeSetsParams returns, for each node set, the orchestrator
+// parameters and environment variables resolved against the global orchestrator
+func orchestratorNodeSetsParams(env model.Environment) map[string]interface{} {
+	res := make(map[string]interface{})
+	for name, n := range env.NodeSets {
+		o, err := n.Orchestrator.Resolve(env)
+		if err != nil {
+			continue
+		}
+		res[name] = map[string]interface{}{
+			"params": map[string]interface{}(o.Parameters()),
+			"env":    map[string]string(o.EnvVars()),
+			"labels": map[string]string(n.Labels),
+		}
+	}
+	return res
+}
+
 func installHookBefore(rC *RuntimeContext) StepResults {
 	sCs := InitStepResults()
 
@@ -409,6 +414,7 @@ func orchestratorInstall(rC *RuntimeContext) StepResults {
 	// Prepare parameters
 	bp := buildBaseParam(rC, "")
 	bp.AddNamedMap("params", o.Parameters())
+	bp.AddNamedMap("nodesets", orchestratorNodeSetsParams(rC.environment))
 	if ko := saveBaseParams(bp, installOrchestratorEf.Input, &sc); ko {
 		sCs.Add(sc)
 		return *sCs
@@ -427,10 +433,11 @@ func orchestratorInstall(rC *RuntimeContext) StepResults {
 	defer usable.Release()
 
 	// Launch the playbook
-	code, err := rC.aM.Play(usable, rC.tplC, installPlaybook, exv)
+	playbook := playbookOf(rC, usable, installPlaybook)
+	code, err := play(rC, &sc, usable, playbook, exv, model.PlayOptions{})
 	if err != nil {
 		pfd := playBookFailureDetail{
-			Playbook:  installPlaybook,
+			Playbook:  playbook,
 			Component: o.ComponentId(),
 			Code:      code,
 		}
@@ -507,6 +514,101 @@ func deployHookBefore(rC *RuntimeContext) StepResults {
 	return *sCs
 }
 
+func volumeCopy(rC *RuntimeContext) StepResults {
+	sCs := InitStepResults()
+
+	if rC.lC.Skipping() > 2 {
+		rC.lC.Feedback().Progress("volume.copy", "Volume copy skipped by user request")
+		return *sCs
+	}
+
+	volumesWithContent := make([]model.GlobalVolume, 0, 0)
+	for _, v := range rC.environment.Volumes.Sorted() {
+		if len(v.Content) > 0 {
+			volumesWithContent = append(volumesWithContent, v)
+		}
+	}
+
+	for _, v := range volumesWithContent {
+		sc := InitPlaybookStepResult("Copying volume content", v, NoCleanUpRequired)
+
+		// Notify volume copy
+		rC.lC.Feedback().ProgressG("volume.copy", len(volumesWithContent), "Copying content into volume '%s'", v.Path)
+
+		// The node sets mounting the volume
+		nodeSets := make([]string, 0)
+		for _, n := range rC.environment.NodeSets {
+			if n.Volumes.Contains(v.Path) {
+				nodeSets = append(nodeSets, n.Name)
+			}
+		}
+		sort.Strings(nodeSets)
+
+		for i, content := range v.Content {
+			rC.lC.Feedback().Detail("Copying '%s' from component '%s'", content.Path, content.ComponentId())
+
+			// Create exchange folder
+			volumeEf, ko := createChildExchangeFolder(rC.lC.Ef().Input, fmt.Sprintf("copy_volume_%s_%d", strings.Trim(strings.Replace(v.Path, "/", "_", -1), "_"), i), &sc)
+			if ko {
+				sCs.Add(sc)
+				return *sCs
+			}
+
+			// Make the component holding the content usable
+			uc, err := rC.cM.Use(content, rC.tplC)
+			if err != nil {
+				FailsOnCode(&sc, err, "An error occurred getting the usable component", nil)
+				sCs.Add(sc)
+				return *sCs
+			}
+			defer uc.Release()
+
+			// If the component is not self copyable, use the orchestrator copy playbook
+			var target componentizer.UsableComponent
+			if ok, _ := uc.ContainsFile(playbookOf(rC, uc, copyPlaybook)); !ok {
+				o, err := rC.cM.Use(rC.environment.Orchestrator, rC.tplC)
+				if err != nil {
+					FailsOnCode(&sc, err, "An error occurred getting the usable orchestrator", nil)
+					sCs.Add(sc)
+					return *sCs
+				}
+				defer o.Release()
+				target = o
+			} else {
+				target = uc
+			}
+
+			// Prepare the extra vars
+			exv := ansible.CreateExtraVars(volumeEf.Input, volumeEf.Output)
+			exv.Add("stack_path", uc.RootPath())
+			exv.Add("copy_path", v.Path)
+			exv.Add("copy_once", "false")
+			exv.AddArray("copy_sources", []string{content.Path})
+			exv.AddArray("copy_nodesets", nodeSets)
+
+			// Execute the playbook
+			playbook := playbookOf(rC, target, copyPlaybook)
+			code, err := play(rC, &sc, target, playbook, exv, model.PlayOptions{})
+			if err != nil {
+				pfd := playBookFailureDetail{
+					Playbook:  playbook,
+					Component: target.Id(),
+					Code:      code,
+				}
+				FailsOnPlaybook(&sc, err, "An error occurred executing the playbook", pfd)
+				sCs.Add(sc)
+				return *sCs
+			}
+		}
+
+		sCs.Add(sc)
+	}
+
+	// Notify volume copy finish
+	rC.lC.Feedback().Progress("volume.copy", "All volume contents have been copied")
+	return *sCs
+}
+
 func stackCopy(rC *RuntimeContext) StepResults {
 	sCs := InitStepResults()
 
@@ -550,7 +652,7 @@ func stackCopy(rC *RuntimeContext) StepResults {
 
 				// If the stack is not self copyable, use the orchestrator copy playbook
 				var target componentizer.UsableComponent
-				if ok, _ := ust.ContainsFile(copyPlaybook); !ok {
+				if ok, _ := ust.ContainsFile(playbookOf(rC, ust, copyPlaybook)); !ok {
 					o, err := rC.cM.Use(rC.environment.Orchestrator, rC.tplC)
 					if err != nil {
 						FailsOnCode(&sc, err, "An error occurred getting the usable orchestrator", nil)
@@ -580,10 +682,11 @@ func stackCopy(rC *RuntimeContext) StepResults {
 				}
 
 				// Execute the playbook
-				code, err := rC.aM.Play(target, rC.tplC, copyPlaybook, exv)
+				playbook := playbookOf(rC, target, copyPlaybook)
+				code, err := play(rC, &sc, target, playbook, exv, model.PlayOptions{})
 				if err != nil {
 					pfd := playBookFailureDetail{
-						Playbook:  copyPlaybook,
+						Playbook:  playbook,
 						Component: target.Id(),
 						Code:      code,
 					}
@@ -628,7 +731,7 @@ func stackCheck(rC *RuntimeContext) StepResults {
 		defer ust.Release()
 
 		//Verify that the stack contains the check playbook
-		if ok, _ := ust.ContainsFile(checkPlaybook); !ok {
+		if ok, _ := ust.ContainsFile(playbookOf(rC, ust, checkPlaybook)); !ok {
 			// Notify stack deploy finish
 			rC.lC.Feedback().Progress("stack.deploy", "No check playbook available for the stack")
 			continue
@@ -654,10 +757,11 @@ func stackCheck(rC *RuntimeContext) StepResults {
 		// Prepare the extra vars
 		exv := ansible.CreateExtraVars(stackEf.Input, stackEf.Output)
 
-		code, err := rC.aM.Play(ust, rC.tplC, checkPlaybook, exv)
+		playbook := playbookOf(rC, ust, checkPlaybook)
+		code, err := play(rC, &sc, ust, playbook, exv, st.Options)
 		if err != nil {
 			pfd := playBookFailureDetail{
-				Playbook:  checkPlaybook,
+				Playbook:  playbook,
 				Component: ust.Id(),
 				Code:      code,
 			}
@@ -729,7 +833,8 @@ func stackDeploy(rC *RuntimeContext) StepResults {
 
 		// If the stack is not self deployable, use the orchestrator deploy playbook
 		var target componentizer.UsableComponent
-		if ok, _ := ust.ContainsFile(deployPlaybook); !ok {
+		var playbook string
+		if ok, _ := ust.ContainsFile(stackPlaybook(rC, st, ust)); !ok && st.Playbook == "" {
 			o, err := rC.cM.Use(rC.environment.Orchestrator, rC.tplC)
 			if err != nil {
 				FailsOnCode(&sc, err, "An error occurred getting the usable orchestrator", nil)
@@ -738,17 +843,19 @@ func stackDeploy(rC *RuntimeContext) StepResults {
 			}
 			defer o.Release()
 			target = o
+			playbook = playbookOf(rC, o, deployPlaybook)
 			exv.Add("stack_path", ust.RootPath())
 			exv.Add("stack_name", st.Name)
 		} else {
 			target = ust
+			playbook = stackPlaybook(rC, st, ust)
 		}
 
 		// Execute the playbook
-		code, err := rC.aM.Play(target, rC.tplC, deployPlaybook, exv)
+		code, err := play(rC, &sc, target, playbook, exv, st.Options)
 		if err != nil {
 			pfd := playBookFailureDetail{
-				Playbook:  deployPlaybook,
+				Playbook:  playbook,
 				Component: target.Id(),
 				Code:      code,
 			}
@@ -821,7 +928,7 @@ func ansibleInventory(rC *RuntimeContext) StepResults {
 	sCs.Add(sc)
 	rC.result = ApplyResult{
 		Success:   true,
-		Inventory: inv,
+		Inventory: inv.Correlate(rC.environment),
 	}
 	rC.lC.Feedback().Progress("inventory", "Inventory generated")
 	return *sCs
@@ -963,6 +1070,8 @@ func createChildExchangeFolder(parent util.FolderPath, name string, sr *StepResu
 }
 
 func saveBaseParams(bp ansible.BaseParam, dest util.FolderPath, sr *StepResult) bool {
+	// The parameters may hold credentials which must not be disclosed
+	secret.MarkSensitiveContent(bp.Body)
 	b, e := bp.Content()
 	if e != nil {
 		FailsOnCode(sr, e, fmt.Sprintf("An error occurred creating the base parameters"), nil)
@@ -120,3 +120,45 @@ qualifier: QualifierContent
 func mockRuntimeContextWithParameters(lC util.LaunchContext) *RuntimeContext {
 	return CreateRuntimeContext(lC, nil, nil, model.Environment{}, &model.TemplateContext{})
 }
+
+func TestOrchestratorNodeSetsParams(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: orchestrator
+orchestrator:
+  params:
+    role: worker
+nodes:
+  "*":
+    orchestrator:
+      params:
+        engine: docker
+  managers:
+    instances: 1
+    labels:
+      kind: manager
+    orchestrator:
+      params:
+        role: manager
+  workers:
+    instances: 2
+`)
+	tester.Init(repDesc.AsRepository("master"))
+
+	res := orchestratorNodeSetsParams(tester.Env())
+	assert.Equal(t, map[string]interface{}{
+		"managers": map[string]interface{}{
+			"params": map[string]interface{}{"role": "manager", "engine": "docker"},
+			"env":    map[string]string{},
+			"labels": map[string]string{"kind": "manager"},
+		},
+		"workers": map[string]interface{}{
+			"params": map[string]interface{}{"role": "worker", "engine": "docker"},
+			"env":    map[string]string{},
+			"labels": map[string]string{},
+		},
+	}, res)
+}
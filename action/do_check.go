@@ -1,6 +1,10 @@
 package action
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/ekara-platform/engine/model"
+)
 
 var (
 	checkAction = Action{
@@ -18,9 +22,19 @@ func doCheck(rC *RuntimeContext) StepResults {
 
 	// Validate the descriptor
 	vErrs := rC.environment.Validate()
+	for _, vErr := range vErrs.Errors {
+		if vErr.Rule == "" {
+			continue
+		}
+		if vErr.ErrorType == model.Error {
+			rC.lC.Feedback().Error("Policy rule %s violated: %s @%s", vErr.Rule, vErr.Message, vErr.Location.Path)
+		} else {
+			rC.lC.Feedback().Info("Policy rule %s warning: %s @%s", vErr.Rule, vErr.Message, vErr.Location.Path)
+		}
+	}
 	if vErrs.HasErrors() {
 		rC.lC.Feedback().Error("Environment model is not valid")
-		FailsOnModel(&sc, fmt.Errorf("model error"), "Environment model is not valid", nil)
+		FailsOnModel(&sc, fmt.Errorf("model error"), "Environment model is not valid", vErrs)
 		return sc.Build()
 	} else {
 		// Validate all components
@@ -40,6 +54,20 @@ func doCheck(rC *RuntimeContext) StepResults {
 			FailsOnComponent(&sc, fmt.Errorf("at least one component is not valid"), "", nil)
 			return sc.Build()
 		}
+
+		// Validate that components provide the playbooks required by their roles
+		failures, err := checkContracts(rC)
+		if err != nil {
+			FailsOnCode(&sc, err, "An error occurred checking the components content", nil)
+			return sc.Build()
+		}
+		if len(failures) > 0 {
+			for _, f := range failures {
+				rC.lC.Feedback().Error("Component %s is not valid: %s", f.Component, f.Error())
+			}
+			FailsOnComponent(&sc, fmt.Errorf("at least one component does not provide the required playbooks"), "", failures)
+			return sc.Build()
+		}
 	}
 
 	rC.lC.Feedback().Progress("check", "Model and components checked")
@@ -0,0 +1,152 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"sort"
+
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/ssh"
+)
+
+var (
+	connectivityAction = Action{
+		ConnectivityActionID,
+		CheckActionID,
+		"Connectivity",
+		[]step{doConnectivity},
+	}
+)
+
+//ConnectivityResult contains the SSH connectivity of each host of the environment
+type ConnectivityResult struct {
+	Hosts []ssh.CheckResult
+}
+
+//IsSuccess returns true if all the hosts can be used through SSH
+func (r ConnectivityResult) IsSuccess() bool {
+	for _, h := range r.Hosts {
+		if !h.Reachable || !h.AuthOK || h.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+//FromJson fills an action returned content from a JSON content
+func (r *ConnectivityResult) FromJson(s string) error {
+	return json.Unmarshal([]byte(s), r)
+}
+
+//AsJson returns the action returned content as JSON
+func (r ConnectivityResult) AsJson() (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// sshTargets returns the hosts of the inventory as SSH targets, sorted by name,
+// connected through the bastion of their node set if any
+func sshTargets(env model.Environment, inv ansible.Inventory) ([]ssh.Target, error) {
+	jumps := make(map[model.Bastion]*ssh.Jump)
+	res := make([]ssh.Target, 0, len(inv.Hosts))
+	for name, h := range inv.Hosts {
+		t := ssh.Target{Name: name, Address: h.Address, Port: h.Port, User: h.User}
+		b := env.Bastion
+		if ns, ok := env.NodeSets[h.NodeSet]; ok {
+			b = ns.EffectiveBastion(env)
+		}
+		if b.IsDefined() {
+			jump, ok := jumps[b]
+			if !ok {
+				var err error
+				jump, err = sshJump(b)
+				if err != nil {
+					return nil, err
+				}
+				jumps[b] = jump
+			}
+			t.Jump = jump
+		}
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// sshJump returns the bastion as SSH jump, authenticated with its own key if any
+func sshJump(b model.Bastion) (*ssh.Jump, error) {
+	res := &ssh.Jump{Target: ssh.Target{Name: b.Host, Address: b.Host, Port: b.Port, User: b.User}}
+	if b.Key != "" {
+		k, err := ssh.LoadKeyPairFiles("", b.Key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the SSH private key of the bastion %s: %s", b.Host, err.Error())
+		}
+		res.Signer, err = k.Signer()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// sshOptions returns the options connecting the hosts with the private key
+// of the engine, as the current user unless the inventory specifies one, just
+// like ansible does. The host keys are checked against the known hosts unless
+// the launch context explicitly accepts any key.
+func sshOptions(rC *RuntimeContext) (ssh.ConnectOptions, error) {
+	k, err := ssh.LoadKeyPairFiles("", rC.lC.SSHPrivateKey(), nil)
+	if err != nil {
+		return ssh.ConnectOptions{}, fmt.Errorf("unable to load the SSH private key: %s", err.Error())
+	}
+	signer, err := k.Signer()
+	if err != nil {
+		return ssh.ConnectOptions{}, err
+	}
+	opts := ssh.ConnectOptions{
+		Signer:                signer,
+		KnownHostsFile:        rC.lC.SSHKnownHostsFile(),
+		InsecureIgnoreHostKey: rC.lC.SSHInsecureHostKeys(),
+	}
+	if u, err := user.Current(); err == nil {
+		opts.User = u.Username
+	}
+	return opts, nil
+}
+
+func doConnectivity(rC *RuntimeContext) StepResults {
+	sc := InitCodeStepResult("Checking the SSH connectivity of the hosts", nil, NoCleanUpRequired)
+
+	rC.lC.Feedback().Progress("connectivity", "Generating inventory")
+	inv, err := rC.aM.Inventory(rC.tplC)
+	if err != nil {
+		FailsOnCode(&sc, err, "An error occurred during inventory", nil)
+		return sc.Build()
+	}
+	opts, err := sshOptions(rC)
+	if err != nil {
+		FailsOnCode(&sc, err, "", nil)
+		return sc.Build()
+	}
+
+	targets, err := sshTargets(rC.environment, inv.Correlate(rC.environment))
+	if err != nil {
+		FailsOnCode(&sc, err, "", nil)
+		return sc.Build()
+	}
+	rC.lC.Feedback().ProgressG("connectivity", len(targets), "Connecting %d host(s)", len(targets))
+	res := ConnectivityResult{Hosts: ssh.CheckAll(targets, opts, ssh.DefaultConcurrency)}
+	for _, h := range res.Hosts {
+		if h.Error != "" {
+			rC.lC.Feedback().Error("Host %s is not usable: %s", h.Host, h.Error)
+		} else {
+			rC.lC.Feedback().Progress("connectivity", "Host %s reachable in %s", h.Host, h.Latency)
+		}
+	}
+	rC.result = res
+	return sc.Build()
+}
@@ -0,0 +1,221 @@
+package action
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/ssh"
+	"github.com/ekara-platform/engine/ssh/sshtest"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+	cssh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// keyLaunchContext uses the given SSH private key, and the known hosts file
+// written along with it
+type keyLaunchContext struct {
+	util.LaunchContext
+	privateKey string
+}
+
+func (lC keyLaunchContext) SSHPrivateKey() string {
+	return lC.privateKey
+}
+
+func (lC keyLaunchContext) SSHKnownHostsFile() string {
+	return filepath.Join(filepath.Dir(lC.privateKey), "known_hosts")
+}
+
+// insecureLaunchContext accepts any host key
+type insecureLaunchContext struct {
+	util.LaunchContext
+}
+
+func (lC insecureLaunchContext) SSHInsecureHostKeys() bool {
+	return true
+}
+
+// trustServer adds the key of the server to the known hosts written along
+// with the given private key
+func trustServer(t *testing.T, key string, s *sshtest.Server) {
+	f, err := os.OpenFile(filepath.Join(filepath.Dir(key), "known_hosts"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	assert.Nil(t, err)
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(s.HostPort())}, s.HostKey) + "\n")
+	assert.Nil(t, err)
+}
+
+// sshServer starts a server accepting a newly generated key, written into the
+// returned file, and known by the hosts file written along with it
+func sshServer(t *testing.T, handler sshtest.CommandHandler) (*sshtest.Server, string, func()) {
+	k, err := ssh.GenerateKeyPair(ssh.KeyOptions{})
+	assert.Nil(t, err)
+	signer, err := k.Signer()
+	assert.Nil(t, err)
+	dir, err := ioutil.TempDir("", "keys")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, util.SSHPrivateKeyFileName)
+	assert.Nil(t, ioutil.WriteFile(path, k.Private, 0600))
+
+	s, err := sshtest.NewServer([]cssh.PublicKey{signer.PublicKey()}, handler)
+	assert.Nil(t, err)
+	trustServer(t, path, s)
+	return s, path, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestConnectivityScenario(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	s, key, stop := sshServer(t, nil)
+	defer stop()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	closedPort := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{
+				"host1": {Name: "host1", Address: s.Address(), Port: s.Port(), User: "ekara", Vars: ansible.InventoryVars{}},
+				"host2": {Name: "host2", Address: "127.0.0.1", Port: closedPort, Vars: ansible.InventoryVars{}},
+			},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = keyLaunchContext{rC.lC, key}
+
+	report, res := connectivityAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.False(t, res.IsSuccess())
+		hosts := res.(ConnectivityResult).Hosts
+		if assert.Len(t, hosts, 2) {
+			assert.Equal(t, "host1", hosts[0].Host)
+			assert.True(t, hosts[0].Reachable)
+			assert.True(t, hosts[0].AuthOK)
+			assert.Equal(t, cssh.FingerprintSHA256(s.HostKey), hosts[0].HostKeyFingerprint)
+			assert.Equal(t, "host2", hosts[1].Host)
+			assert.False(t, hosts[1].Reachable)
+			assert.NotEqual(t, "", hosts[1].Error)
+		}
+		_, err := res.AsJson()
+		assert.Nil(t, err)
+	}
+	assert.Empty(t, playedPlaybooks(aM))
+}
+
+func TestConnectivityScenarioUnknownHost(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	s, key, stop := sshServer(t, nil)
+	defer stop()
+	other, err := sshtest.NewServer(nil, nil)
+	assert.Nil(t, err)
+	defer other.Close()
+
+	// The server listening on the address of the known host presents another key
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{
+				"host1": {Name: "host1", Address: s.Address(), Port: other.Port(), Vars: ansible.InventoryVars{}},
+			},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = keyLaunchContext{rC.lC, key}
+
+	report, res := connectivityAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.False(t, res.IsSuccess())
+		host := res.(ConnectivityResult).Hosts[0]
+		assert.True(t, host.Reachable)
+		assert.False(t, host.AuthOK)
+		assert.Contains(t, host.Error, "knownhosts")
+	}
+
+	// Any host key is accepted only when explicitly requested
+	rC.lC = insecureLaunchContext{rC.lC}
+	report, res = connectivityAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		host := res.(ConnectivityResult).Hosts[0]
+		assert.True(t, host.Reachable)
+		assert.NotContains(t, host.Error, "knownhosts")
+	}
+}
+
+func TestConnectivityScenarioMissingKey(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = keyLaunchContext{rC.lC, "missing.pem"}
+
+	report, res := connectivityAction.Execute(rC)
+	assert.Nil(t, res)
+	if assert.NotNil(t, report.Error) {
+		assert.Contains(t, report.Error.Error(), "unable to load the SSH private key")
+	}
+}
+
+func TestConnectivityScenarioBastion(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	s, key, stop := sshServer(t, nil)
+	defer stop()
+	k, err := ssh.LoadKeyPairFiles("", key, nil)
+	assert.Nil(t, err)
+	signer, err := k.Signer()
+	assert.Nil(t, err)
+	bastion, err := sshtest.NewServer([]cssh.PublicKey{signer.PublicKey()}, nil)
+	assert.Nil(t, err)
+	defer bastion.Close()
+	trustServer(t, key, bastion)
+
+	// The hosts are connected through the global bastion, with the engine key
+	descriptor := scenarioDescriptor + fmt.Sprintf("bastion:\n  host: %s\n  port: %d\n", bastion.Address(), bastion.Port())
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{
+				"host1": {Name: "host1", Address: s.Address(), Port: s.Port(), NodeSet: "node1", Vars: ansible.InventoryVars{}},
+			},
+		},
+	})
+	rC, clean := scenarioRuntimeContextWith(t, tester, aM, descriptor)
+	defer clean()
+	rC.lC = keyLaunchContext{rC.lC, key}
+
+	report, res := connectivityAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.True(t, res.IsSuccess())
+	}
+
+	// The bastion cannot be used once stopped
+	bastion.Close()
+	report, res = connectivityAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.False(t, res.IsSuccess())
+		assert.Contains(t, res.(ConnectivityResult).Hosts[0].Error, "through the bastion")
+	}
+}
@@ -3,7 +3,6 @@ package action
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/ekara-platform/engine/ansible"
 )
 
 const (
@@ -105,40 +104,20 @@ func providerDestroy(rC *RuntimeContext) StepResults {
 			NoCleanUpRequired,
 		)
 
-		// Node destruction exchange folder
-		destroy, ko := createChildExchangeFolder(rC.lC.Ef().Input, "destroy_"+n.Name, &sc)
-		if ko {
-			sCs.Add(sc)
-			return *sCs
-		}
-
-		if ko := saveBaseParams(bp, destroy.Input, &sc); ko {
-			sCs.Add(sc)
-			return *sCs
-		}
-
-		// Prepare extra vars
-		exv := ansible.CreateExtraVars(destroy.Input, destroy.Output)
-
-		// Make the component usable
-		usable, err := rC.cM.Use(p, rC.tplC)
-		if err != nil {
-			FailsOnCode(&sc, err, "An error occurred getting the usable provider", nil)
-		}
-		defer usable.Release()
-
-		// Launch the playbook
-		code, err := rC.aM.Play(usable, rC.tplC, destroyPlaybook, exv)
-
-		if err != nil {
-			pfd := playBookFailureDetail{
-				Playbook:  destroyPlaybook,
-				Component: p.ComponentId(),
-				Code:      code,
+		// Launch the provider playbook, the hosts of a static provider are left untouched
+		if p.IsStatic() {
+			rC.lC.Log().Printf("Node set %s uses existing hosts, nothing to destroy", n.Name)
+		} else {
+			// The hosts are only known before their destruction
+			invalidateFacts(rC, n.Name)
+			ko := providerPlay(rC, &sc, p, bp, "destroy_"+n.Name, destroyPlaybook)
+			// The nodes change, even partially, so the inventory must be generated
+			// again, before running the hooks
+			rC.aM.InvalidateInventory()
+			if ko {
+				sCs.Add(sc)
+				return *sCs
 			}
-			FailsOnPlaybook(&sc, err, "An error occurred executing the playbook", pfd)
-			sCs.Add(sc)
-			return *sCs
 		}
 		sCs.Add(sc)
 
@@ -3,6 +3,7 @@ package action
 import (
 	"errors"
 	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
 	"gopkg.in/yaml.v2"
 )
 
@@ -41,7 +42,7 @@ func (r DumpResult) AsYaml() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return string(envYaml), nil
+	return secret.Hide(string(envYaml)), nil
 }
 
 func doDump(rC *RuntimeContext) StepResults {
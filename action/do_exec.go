@@ -0,0 +1,127 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/ssh"
+	"github.com/ekara-platform/engine/util"
+)
+
+var (
+	execAction = Action{
+		ExecActionID,
+		CheckActionID,
+		"Exec",
+		[]step{doExec},
+	}
+)
+
+//ExecResult contains the outcome of the command run on each targeted host
+type ExecResult struct {
+	Command string
+	Hosts   []ssh.RunResult
+}
+
+//IsSuccess returns true if the command succeeded on all the targeted hosts
+func (r ExecResult) IsSuccess() bool {
+	for _, h := range r.Hosts {
+		if h.ExitCode != 0 || h.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+//FromJson fills an action returned content from a JSON content
+func (r *ExecResult) FromJson(s string) error {
+	return json.Unmarshal([]byte(s), r)
+}
+
+//AsJson returns the action returned content as JSON
+func (r ExecResult) AsJson() (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// execHosts returns the part of the inventory holding the hosts targeted by the options
+func execHosts(inv ansible.Inventory, opts util.ExecOptions) (ansible.Inventory, error) {
+	if opts.Group != "" {
+		inv = inv.Select(ansible.InventorySelector{Groups: []string{opts.Group}})
+	}
+	selector, err := model.ParseLabelSelector(opts.Labels)
+	if err != nil {
+		return inv, err
+	}
+	res := ansible.Inventory{Hosts: make(map[string]ansible.Host), Groups: inv.Groups}
+	for _, h := range inv.BySelector(selector) {
+		if opts.NodeSet == "" || h.NodeSet == opts.NodeSet {
+			res.Hosts[h.Name] = h
+		}
+	}
+	return res, nil
+}
+
+func doExec(rC *RuntimeContext) StepResults {
+	opts := rC.lC.ExecOptions()
+	sc := InitCodeStepResult("Running the command on the hosts", nil, NoCleanUpRequired)
+	if opts.Command == "" {
+		FailsOnCode(&sc, errors.New("no command to run"), "", nil)
+		return sc.Build()
+	}
+
+	rC.lC.Feedback().Progress("exec", "Generating inventory")
+	inv, err := rC.aM.Inventory(rC.tplC)
+	if err != nil {
+		FailsOnCode(&sc, err, "An error occurred during inventory", nil)
+		return sc.Build()
+	}
+	inv, err = execHosts(inv.Correlate(rC.environment), opts)
+	if err != nil {
+		FailsOnCode(&sc, err, "Invalid host selection", nil)
+		return sc.Build()
+	}
+	if len(inv.Hosts) == 0 {
+		FailsOnCode(&sc, fmt.Errorf("no host matches the selection"), "", nil)
+		return sc.Build()
+	}
+	sshOpts, err := sshOptions(rC)
+	if err != nil {
+		FailsOnCode(&sc, err, "", nil)
+		return sc.Build()
+	}
+
+	targets, err := sshTargets(rC.environment, inv)
+	if err != nil {
+		FailsOnCode(&sc, err, "", nil)
+		return sc.Build()
+	}
+	rC.lC.Feedback().ProgressG("exec", len(targets), "Running \"%s\" on %d host(s)", opts.Command, len(targets))
+	res := ExecResult{
+		Command: opts.Command,
+		Hosts: ssh.RunAll(targets, sshOpts, opts.Command, opts.Concurrency, func(host string, line string, stderr bool) {
+			if stderr {
+				rC.lC.Feedback().Detail("%s (stderr): %s", host, line)
+			} else {
+				rC.lC.Feedback().Detail("%s: %s", host, line)
+			}
+		}),
+	}
+	for _, h := range res.Hosts {
+		if h.Error != "" {
+			rC.lC.Feedback().Error("Command not run on host %s: %s", h.Host, h.Error)
+		} else if h.ExitCode != 0 {
+			rC.lC.Feedback().Error("Command failed on host %s (%d)", h.Host, h.ExitCode)
+		} else {
+			rC.lC.Feedback().Progress("exec", "Command completed on host %s", h.Host)
+		}
+	}
+	rC.result = res
+	return sc.Build()
+}
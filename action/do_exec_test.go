@@ -0,0 +1,133 @@
+package action
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// execLaunchContext runs the given command with the given SSH private key
+type execLaunchContext struct {
+	keyLaunchContext
+	opts util.ExecOptions
+}
+
+func (lC execLaunchContext) ExecOptions() util.ExecOptions {
+	return lC.opts
+}
+
+func execInventory() ansible.Inventory {
+	return ansible.Inventory{
+		Hosts: map[string]ansible.Host{
+			"host1": {Name: "host1", NodeSet: "node1", Vars: ansible.InventoryVars{"labels": map[string]interface{}{"role": "web"}}},
+			"host2": {Name: "host2", NodeSet: "node1", Vars: ansible.InventoryVars{"labels": map[string]interface{}{"role": "db"}}},
+			"host3": {Name: "host3", Vars: ansible.InventoryVars{"labels": map[string]interface{}{"role": "db"}}},
+		},
+		Groups: map[string]ansible.Group{
+			"databases": {Hosts: []string{"host2", "host3"}},
+		},
+	}
+}
+
+func TestExecHosts(t *testing.T) {
+	hostNames := func(inv ansible.Inventory) []string {
+		res := []string{}
+		for name := range inv.Hosts {
+			res = append(res, name)
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	inv, err := execHosts(execInventory(), util.ExecOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host1", "host2", "host3"}, hostNames(inv))
+
+	inv, err = execHosts(execInventory(), util.ExecOptions{NodeSet: "node1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host1", "host2"}, hostNames(inv))
+
+	inv, err = execHosts(execInventory(), util.ExecOptions{NodeSet: "node1", Labels: "role=db"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host2"}, hostNames(inv))
+
+	inv, err = execHosts(execInventory(), util.ExecOptions{Group: "databases", Labels: "role!=web"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"host2", "host3"}, hostNames(inv))
+
+	inv, err = execHosts(execInventory(), util.ExecOptions{Group: "unknown"})
+	assert.Nil(t, err)
+	assert.Empty(t, hostNames(inv))
+
+	_, err = execHosts(execInventory(), util.ExecOptions{Labels: "role in db"})
+	assert.NotNil(t, err)
+}
+
+func TestExecScenario(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	s, key, stop := sshServer(t, func(user string, command string) (string, string, int) {
+		if user == "broken" {
+			return "", "disk full\n", 1
+		}
+		return "42%\n", "", 0
+	})
+	defer stop()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{
+				"host1": {Name: "host1", Address: s.Address(), Port: s.Port(), User: "ekara", NodeSet: "node1", Vars: ansible.InventoryVars{}},
+				"host2": {Name: "host2", Address: s.Address(), Port: s.Port(), User: "broken", NodeSet: "node1", Vars: ansible.InventoryVars{}},
+				"host3": {Name: "host3", Address: s.Address(), Port: s.Port(), Vars: ansible.InventoryVars{}},
+			},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = execLaunchContext{keyLaunchContext{rC.lC, key}, util.ExecOptions{Command: "df", NodeSet: "node1", Concurrency: 1}}
+
+	report, res := execAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.False(t, res.IsSuccess())
+		r := res.(ExecResult)
+		assert.Equal(t, "df", r.Command)
+		if assert.Len(t, r.Hosts, 2) {
+			assert.Equal(t, "host1", r.Hosts[0].Host)
+			assert.Equal(t, 0, r.Hosts[0].ExitCode)
+			assert.Equal(t, "42%\n", r.Hosts[0].Stdout)
+			assert.Equal(t, "host2", r.Hosts[1].Host)
+			assert.Equal(t, 1, r.Hosts[1].ExitCode)
+			assert.Equal(t, "disk full\n", r.Hosts[1].Stderr)
+		}
+	}
+}
+
+func TestExecScenarioNoHost(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{Inventory: execInventory()})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	rC.lC = execLaunchContext{keyLaunchContext{rC.lC, ""}, util.ExecOptions{}}
+	report, res := execAction.Execute(rC)
+	assert.Nil(t, res)
+	if assert.NotNil(t, report.Error) {
+		assert.Equal(t, "no command to run", report.Error.Error())
+	}
+
+	rC.lC = execLaunchContext{keyLaunchContext{rC.lC, ""}, util.ExecOptions{Command: "df", NodeSet: "missing"}}
+	report, res = execAction.Execute(rC)
+	assert.Nil(t, res)
+	if assert.NotNil(t, report.Error) {
+		assert.Equal(t, "no host matches the selection", report.Error.Error())
+	}
+}
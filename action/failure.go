@@ -2,6 +2,9 @@ package action
 
 import (
 	"encoding/json"
+	"reflect"
+
+	"github.com/ekara-platform/engine/secret"
 )
 
 type (
@@ -59,6 +62,15 @@ func failOn(fc failureCause) func(sr *StepResult, err error, detail string, cont
 		sr.Status = stepStatusFailure
 		if content != nil {
 			bs, _ := json.Marshal(content)
+			// Hide the sensitive values, including the ones held by the content
+			// itself, within the content before serializing it again
+			var generic interface{}
+			if json.Unmarshal(bs, &generic) == nil {
+				secret.MarkSensitiveContent(generic)
+				if hidden := secret.HideContent(generic); !reflect.DeepEqual(hidden, generic) {
+					bs, _ = json.Marshal(hidden)
+				}
+			}
 			sr.RawContent = string(bs)
 		}
 	}
@@ -69,6 +69,11 @@ func runHooks(hooks []model.TaskRef, rC *RuntimeContext, r *StepResults, ctx hoo
 func fConsumeHookResult(rC *RuntimeContext, target model.Describable, ctx hookContext, ef util.ExchangeFolder, prefix string) StepResult {
 	sc := InitCodeStepResult("Consuming the hook result", target, NoCleanUpRequired)
 
+	// The playbooks launched in check mode produce no relevant result
+	if rC.lC.CheckMode() {
+		return sc
+	}
+
 	if ef.Output.Contains(util.OutputYamlFileName) {
 		filePath := util.JoinPaths(ef.Output.Path(), util.OutputYamlFileName)
 		b, err := util.FileRead(filePath)
@@ -112,7 +117,7 @@ func runTask(rC *RuntimeContext, task model.Task, sc StepResult, r *StepResults,
 	}
 	defer usable.Release()
 
-	code, err := rC.aM.Play(usable, rC.tplC, task.Playbook, exv)
+	code, err := play(rC, &sc, usable, task.Playbook, exv, task.Options)
 	if err != nil {
 		pfd := playBookFailureDetail{
 			Playbook:  task.Playbook,
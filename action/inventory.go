@@ -0,0 +1,43 @@
+package action
+
+import (
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+)
+
+// runtimeInventory gives the templates access to the inventory, generated
+// on demand and then reused until the nodes change
+type runtimeInventory struct {
+	rC *RuntimeContext
+}
+
+func (i runtimeInventory) inventory() (ansible.TemplateInventory, error) {
+	ctx := i.rC.tplC
+	if tplC, ok := ctx.(*model.TemplateContext); ok {
+		// The inventory sources cannot refer to the inventory itself
+		c := *tplC
+		c.Inventory = nil
+		ctx = &c
+	}
+	inv, err := i.rC.aM.Inventory(ctx)
+	if err != nil {
+		return ansible.TemplateInventory{}, err
+	}
+	return ansible.TemplateInventory{Inventory: inv.Correlate(i.rC.environment)}, nil
+}
+
+func (i runtimeInventory) ByNodeSet(name string) ([]model.InventoryHost, error) {
+	inv, err := i.inventory()
+	if err != nil {
+		return nil, err
+	}
+	return inv.ByNodeSet(name)
+}
+
+func (i runtimeInventory) BySelector(selector string) ([]model.InventoryHost, error) {
+	inv, err := i.inventory()
+	if err != nil {
+		return nil, err
+	}
+	return inv.BySelector(selector)
+}
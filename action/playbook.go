@@ -0,0 +1,149 @@
+package action
+
+import (
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
+	"github.com/ekara-platform/engine/util"
+)
+
+// playbookOf returns the playbook to launch into the component in place of
+// the given default one, taking into account the custom playbooks declared
+// by the component
+func playbookOf(rC *RuntimeContext, uc componentizer.UsableComponent, defaultPlaybook string) string {
+	return rC.environment.Platform.Playbook(uc.Id(), defaultPlaybook)
+}
+
+// stackPlaybook returns the playbook deploying the stack, the custom playbook
+// of the stack takes precedence over the ones declared by its component
+func stackPlaybook(rC *RuntimeContext, st model.Stack, ust componentizer.UsableComponent) string {
+	if st.Playbook != "" {
+		return st.Playbook
+	}
+	return playbookOf(rC, ust, deployPlaybook)
+}
+
+// play launches the playbook of the component targeting the hosts of the
+// environment, recording into the step result the options used and the
+// changes reported in check mode.
+//
+// The options of the launch context override the given descriptor defaults.
+func play(rC *RuntimeContext, sc *StepResult, uc componentizer.UsableComponent, playbook string, exv ansible.ExtraVars, defaults model.PlayOptions) (int, error) {
+	return launch(rC, sc, uc, playbook, exv, defaults.Override(rC.lC.PlayOptions()))
+}
+
+// playLocally launches the playbook of the component running on the engine
+// side, like the ones of the providers, the options of the launch context
+// targeting the hosts and the tasks of the environment don't apply there
+func playLocally(rC *RuntimeContext, sc *StepResult, uc componentizer.UsableComponent, playbook string, exv ansible.ExtraVars) (int, error) {
+	return launch(rC, sc, uc, playbook, exv, model.PlayOptions{})
+}
+
+// launch launches the playbook of the component with the given options
+func launch(rC *RuntimeContext, sc *StepResult, uc componentizer.UsableComponent, playbook string, exv ansible.ExtraVars, options model.PlayOptions) (int, error) {
+	if !options.IsEmpty() {
+		sc.PlayOptions = &options
+	}
+	code, err := rC.aM.Play(uc, rC.tplC, playbook, exv, options)
+	captureConfig(rC, exv, sc)
+	captureDiscovery(rC, exv, sc)
+	captureDiff(rC, exv, sc)
+	return code, err
+}
+
+// captureConfig stores into the step result the ansible configuration used to
+// launch a playbook, to be able to tune the slow executions
+func captureConfig(rC *RuntimeContext, exv ansible.ExtraVars, sc *StepResult) {
+	in, ok := exv.Content["input_dir"].(string)
+	if !ok {
+		return
+	}
+	b, err := util.FileRead(util.JoinPaths(in, ansible.ConfigFileName))
+	if err != nil {
+		rC.lC.Log().Printf("No ansible configuration generated into %s", in)
+		return
+	}
+	sc.AnsibleConfig = secret.Hide(string(b))
+}
+
+// captureDiscovery stores into the step result the roles, collections, plugins
+// and libraries discovered across the components to launch a playbook
+func captureDiscovery(rC *RuntimeContext, exv ansible.ExtraVars, sc *StepResult) {
+	in, ok := exv.Content["input_dir"].(string)
+	if !ok {
+		return
+	}
+	d, err := ansible.ReadDiscovery(in)
+	if err != nil {
+		rC.lC.Log().Printf("No directory discovered for the playbook into %s", in)
+		return
+	}
+	sc.Discovery = d
+}
+
+// captureDiff stores into the step result the changes reported by a playbook
+// launched in check mode
+func captureDiff(rC *RuntimeContext, exv ansible.ExtraVars, sc *StepResult) {
+	if !rC.lC.CheckMode() {
+		return
+	}
+	out, ok := exv.Content["output_dir"].(string)
+	if !ok {
+		return
+	}
+	b, err := util.FileRead(util.JoinPaths(out, util.DiffFileName))
+	if err != nil {
+		rC.lC.Log().Printf("No changes reported by the playbook into %s", out)
+		return
+	}
+	sc.Diff = secret.Hide(string(b))
+}
+
+// providerPlay launches a playbook of the provider on a node set, from the
+// given child exchange folder, and returns true if it fails
+func providerPlay(rC *RuntimeContext, sc *StepResult, p model.Provider, bp ansible.BaseParam, folder string, defaultPlaybook string) bool {
+	ef, ko := createChildExchangeFolder(rC.lC.Ef().Input, folder, sc)
+	if ko {
+		return true
+	}
+	if ko := saveBaseParams(bp, ef.Input, sc); ko {
+		return true
+	}
+
+	// Prepare extra vars
+	exv := ansible.CreateExtraVars(ef.Input, ef.Output)
+
+	// Make the provider usable
+	usable, err := rC.cM.Use(p, rC.tplC)
+	if err != nil {
+		FailsOnCode(sc, err, "An error occurred getting the usable provider", nil)
+		return true
+	}
+	defer usable.Release()
+
+	// Launch the playbook
+	playbook := playbookOf(rC, usable, defaultPlaybook)
+	code, err := playLocally(rC, sc, usable, playbook, exv)
+	if err != nil {
+		pfd := playBookFailureDetail{
+			Playbook:  playbook,
+			Component: p.ComponentId(),
+			Code:      code,
+		}
+		FailsOnPlaybook(sc, err, "An error occurred executing the playbook", pfd)
+		return true
+	}
+	return false
+}
+
+// invalidateFacts discards the facts cached for the hosts of the node sets,
+// which are only logged on failure as they would be gathered again anyway
+func invalidateFacts(rC *RuntimeContext, nodeSets ...string) {
+	if len(nodeSets) == 0 {
+		return
+	}
+	if err := rC.aM.InvalidateFacts(rC.tplC, nodeSets...); err != nil {
+		rC.lC.Log().Printf("Unable to invalidate the facts of the node sets %v: %s", nodeSets, err.Error())
+	}
+}
@@ -43,9 +43,17 @@ func (er ExecutionReport) Write(path util.FolderPath) (string, error) {
 	return loc, nil
 }
 
-// Content returns the json representation of the report steps
+// Content returns the json representation of the report steps, the
+// sensitive values are hidden
 func (er ExecutionReport) Content() (b []byte, e error) {
-	b, e = json.MarshalIndent(&er.Steps, "", "    ")
+	steps := StepResults{
+		Status:             make([]StepResult, 0, len(er.Steps.Status)),
+		TotalExecutionTime: er.Steps.TotalExecutionTime,
+	}
+	for _, sr := range er.Steps.Status {
+		steps.Status = append(steps.Status, sr.hidden())
+	}
+	b, e = json.MarshalIndent(&steps, "", "    ")
 	return
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ekara-platform/engine/secret"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -160,3 +161,15 @@ qualifier: QualifierContent
 	assert.Equal(t, 1, len(cpt.playBookFailures))
 }
 */
+
+func TestReportContentHidesSecrets(t *testing.T) {
+	secret.MarkSensitive("report-secret")
+
+	sc := InitCodeStepResult("DUMMY_STEP", nil, NoCleanUpRequired)
+	FailsOnCode(&sc, fmt.Errorf("failure with report-secret"), "detail report-secret", nil)
+	r := ExecutionReport{Steps: sc.Build()}
+	b, err := r.Content()
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "report-secret")
+	assert.Contains(t, string(b), secret.Mask)
+}
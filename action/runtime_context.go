@@ -29,5 +29,8 @@ func CreateRuntimeContext(lC util.LaunchContext, cM componentizer.ComponentManag
 		environment: env,
 		tplC:        tplC,
 	}
+	if tplC, ok := tplC.(*model.TemplateContext); ok && aM != nil {
+		tplC.Inventory = runtimeInventory{rC: rC}
+	}
 	return rC
 }
@@ -0,0 +1,430 @@
+package action
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const scenarioDescriptor = `
+name: scenario
+ekara:
+  components:
+    provider:
+      repository: provider
+    orchestrator:
+      repository: orchestrator
+    stack:
+      repository: stack
+    task:
+      repository: task
+orchestrator:
+  component: orchestrator
+providers:
+  p1:
+    component: provider
+    params:
+      region: eu-west
+tasks:
+  notify:
+    component: task
+    playbook: notify.yaml
+nodes:
+  node1:
+    instances: 2
+    provider:
+      name: p1
+    hooks:
+      create:
+        after:
+          - task: notify
+            prefix: created
+stacks:
+  stack1:
+    component: stack
+    options:
+      tags:
+        - deploy
+hooks:
+  delete:
+    before:
+      - task: notify
+`
+
+// scenarioRuntimeContext creates the components of the scenario descriptor
+// and returns a runtime context launching the playbooks through the given
+// scripted manager
+func scenarioRuntimeContext(t *testing.T, tester util.EkaraComponentTester, aM ansible.Manager) (*RuntimeContext, func()) {
+	return scenarioRuntimeContextWith(t, tester, aM, scenarioDescriptor)
+}
+
+// scenarioRuntimeContextWith does the same as scenarioRuntimeContext for the
+// given descriptor
+func scenarioRuntimeContextWith(t *testing.T, tester util.EkaraComponentTester, aM ansible.Manager, descriptor string) (*RuntimeContext, func()) {
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("setup.yaml", "")
+	repProvider.WriteCommit("create.yaml", "")
+	repProvider.WriteCommit("destroy.yaml", "")
+
+	repOrchestrator := tester.CreateDir("orchestrator")
+	repOrchestrator.WriteCommit("setup.yaml", "")
+	repOrchestrator.WriteCommit("install.yaml", "")
+
+	repStack := tester.CreateDir("stack")
+	repStack.WriteCommit("deploy.yaml", "")
+
+	repTask := tester.CreateDir("task")
+	repTask.WriteCommit("notify.yaml", "")
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", descriptor)
+	tester.Init(repDesc.AsRepository("master"))
+
+	dir, err := ioutil.TempDir("", "scenario")
+	assert.Nil(t, err)
+	ef, err := util.CreateExchangeFolder(dir, "ef")
+	assert.Nil(t, err)
+	assert.Nil(t, ef.Create())
+
+	lC := util.CreateMockLaunchContextWithDataAndFolder(model.CreateEmptyParameters(), ef, false)
+	rC := CreateRuntimeContext(lC, tester.ComponentManager(), aM, tester.Env(), tester.TemplateContext())
+	return rC, func() { os.RemoveAll(dir) }
+}
+
+func playedPlaybooks(aM *ansible.ScriptedManager) []string {
+	res := make([]string, 0)
+	for _, c := range aM.Calls() {
+		res = append(res, c.Component+":"+c.Playbook)
+	}
+	return res
+}
+
+func TestApplyScenario(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Outcomes: []ansible.ScriptedOutcome{
+			{Component: "task", Playbook: "notify.yaml", Output: "message: done\n"},
+		},
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{"host1": {Name: "host1", Vars: ansible.InventoryVars{}}},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	report, res := applyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	if assert.NotNil(t, res) {
+		assert.True(t, res.IsSuccess())
+		assert.Contains(t, res.(ApplyResult).Inventory.Hosts, "host1")
+	}
+
+	assert.Equal(t, []string{
+		"provider:setup.yaml",
+		"provider:create.yaml",
+		"task:notify.yaml",
+		"orchestrator:setup.yaml",
+		"orchestrator:install.yaml",
+		"stack:deploy.yaml",
+	}, playedPlaybooks(aM))
+
+	// The parameters and the extra vars are passed to the playbooks
+	create := aM.Played("provider", "create.yaml")
+	if assert.Len(t, create, 1) {
+		assert.Contains(t, create[0].Params, "instances: 2")
+		assert.Contains(t, create[0].Params, "region: eu-west")
+		assert.Contains(t, create[0].ExtraVars, "input_dir")
+		assert.Contains(t, create[0].ExtraVars, "output_dir")
+		assert.Contains(t, create[0].Config, "[defaults]")
+		assert.Empty(t, create[0].InvalidatedFacts)
+	}
+
+	// The ansible configuration of the playbooks is recorded into the report
+	configs := 0
+	for _, s := range report.Steps.Status {
+		if s.AnsibleConfig != "" {
+			configs++
+		}
+	}
+	assert.Equal(t, len(aM.Calls()), configs)
+
+	// The inventory is generated again once the nodes are created, and their facts gathered again
+	assert.Equal(t, 1, aM.Invalidations())
+	assert.Equal(t, []string{"node1"}, aM.InvalidatedFacts())
+
+	// The hooks following the creation use the new inventory and gather the facts again
+	if notify := aM.Played("task", "notify.yaml"); assert.Len(t, notify, 1) {
+		assert.Equal(t, 1, notify[0].Invalidations)
+		assert.Equal(t, []string{"node1"}, notify[0].InvalidatedFacts)
+	}
+
+	// The output of the hook is available to the following templates
+	runtime := rC.tplC.(*model.TemplateContext).Runtime
+	assert.Equal(t, map[string]interface{}{"message": "done"}, runtime["created"])
+}
+
+func TestApplyScenarioFailure(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Outcomes: []ansible.ScriptedOutcome{
+			{Component: "orchestrator", Playbook: "install.yaml", Code: 2},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	report, res := applyAction.Execute(rC)
+	assert.Nil(t, res)
+	if assert.NotNil(t, report.Error) {
+		assert.Equal(t, "playbook did not complete successfully (2), check the logs for details", report.Error.Error())
+	}
+
+	// The execution stops on the failing playbook
+	assert.Equal(t, []string{
+		"provider:setup.yaml",
+		"provider:create.yaml",
+		"task:notify.yaml",
+		"orchestrator:setup.yaml",
+		"orchestrator:install.yaml",
+	}, playedPlaybooks(aM))
+
+	last := report.Steps.Status[len(report.Steps.Status)-1]
+	assert.Equal(t, stepStatusFailure, last.Status)
+	assert.Equal(t, playBookFailure, last.FailureCause)
+	assert.Equal(t, `{"Playbook":"install.yaml","Component":"orchestrator","Code":2}`, last.RawContent)
+}
+
+func TestApplyScenarioInventoryFailure(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{InventoryError: "no inventory"})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	report, res := applyAction.Execute(rC)
+	assert.Nil(t, res)
+	if assert.NotNil(t, report.Error) {
+		assert.Equal(t, "no inventory", report.Error.Error())
+	}
+	assert.Len(t, aM.Calls(), 6)
+}
+
+func TestDestroyScenario(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	report, _ := destroyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	assert.Equal(t, []string{
+		"provider:setup.yaml",
+		"task:notify.yaml",
+		"provider:destroy.yaml",
+	}, playedPlaybooks(aM))
+	assert.Equal(t, 1, aM.Invalidations())
+	assert.Equal(t, []string{"node1"}, aM.InvalidatedFacts())
+}
+
+func TestDestroyScenarioHookFailure(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Outcomes: []ansible.ScriptedOutcome{
+			{Playbook: "notify.yaml", Error: "unable to notify"},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	report, _ := destroyAction.Execute(rC)
+	if assert.NotNil(t, report.Error) {
+		assert.Equal(t, "unable to notify", report.Error.Error())
+	}
+
+	// Nothing is destroyed if the hook fails
+	assert.Len(t, aM.Played("provider", "destroy.yaml"), 0)
+}
+
+// checkModeLaunchContext launches the playbooks in check mode
+type checkModeLaunchContext struct {
+	util.LaunchContext
+}
+
+func (lC checkModeLaunchContext) CheckMode() bool {
+	return true
+}
+
+func TestApplyScenarioCheckMode(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Outcomes: []ansible.ScriptedOutcome{
+			{Component: "task", Playbook: "notify.yaml", Output: "message: done\n"},
+			{Component: "stack", Playbook: "deploy.yaml", Diff: "--- before\n+++ after\n+replicas: 2"},
+		},
+	})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = checkModeLaunchContext{rC.lC}
+
+	report, _ := applyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	assert.Len(t, aM.Calls(), 6)
+
+	// The changes are reported by step
+	diffs := make(map[string]string)
+	for _, s := range report.Steps.Status {
+		if s.Diff != "" {
+			diffs[s.AppliedToName] = s.Diff
+		}
+	}
+	assert.Equal(t, map[string]string{"stack1": "--- before\n+++ after\n+replicas: 2"}, diffs)
+
+	// The output of the hook doesn't pollute the templates
+	_, ok := rC.tplC.(*model.TemplateContext).Runtime["created"]
+	assert.False(t, ok)
+}
+
+// optionsLaunchContext launches the playbooks with the given options
+type optionsLaunchContext struct {
+	util.LaunchContext
+	options model.PlayOptions
+}
+
+func (lC optionsLaunchContext) PlayOptions() model.PlayOptions {
+	return lC.options
+}
+
+func TestApplyScenarioPlayOptions(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{})
+	rC, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+	rC.lC = optionsLaunchContext{rC.lC, model.PlayOptions{Limit: "node-3"}}
+
+	report, _ := applyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+
+	// The launch context options override the descriptor ones
+	if deploy := aM.Played("stack", "deploy.yaml"); assert.Len(t, deploy, 1) {
+		assert.Equal(t, model.PlayOptions{Limit: "node-3", Tags: []string{"deploy"}}, deploy[0].Options)
+	}
+	// But not the playbooks running on the engine side
+	if create := aM.Played("provider", "create.yaml"); assert.Len(t, create, 1) {
+		assert.True(t, create[0].Options.IsEmpty())
+	}
+	if setup := aM.Played("provider", "setup.yaml"); assert.Len(t, setup, 1) {
+		assert.True(t, setup[0].Options.IsEmpty())
+	}
+
+	// The options are reported by step
+	found := false
+	for _, s := range report.Steps.Status {
+		if s.AppliedToName == "stack1" && s.StepName == "Deploying stack" {
+			found = true
+			if assert.NotNil(t, s.PlayOptions) {
+				assert.Equal(t, []string{"deploy"}, s.PlayOptions.Tags)
+			}
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestScenarioTemplateInventory(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	aM := ansible.CreateScriptedManager(ansible.Scenario{
+		Inventory: ansible.Inventory{
+			Hosts: map[string]ansible.Host{
+				"host1": {Name: "host1", Address: "10.0.0.1", NodeSet: "node1", Vars: ansible.InventoryVars{}},
+				"host2": {Name: "host2", Address: "10.0.0.2", NodeSet: "node1", Vars: ansible.InventoryVars{}},
+				"host3": {Name: "host3", Address: "10.0.0.3", Vars: ansible.InventoryVars{"labels": map[string]interface{}{"role": "db"}}},
+			},
+		},
+	})
+	_, clean := scenarioRuntimeContext(t, tester, aM)
+	defer clean()
+
+	// The stacks can be configured with the addresses of the hosts
+	tplC := tester.TemplateContext().(*model.TemplateContext)
+	res, err := tplC.Execute(`{{ range .Inventory.ByNodeSet "node1" }}{{ .Address }} {{ end }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1 10.0.0.2 ", res)
+
+	res, err = tplC.Execute(`{{ range .Inventory.BySelector "role in (db,cache)" }}{{ .Name }}{{ end }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "host3", res)
+
+	_, err = tplC.Execute(`{{ .Inventory.BySelector "role in db" }}`)
+	assert.NotNil(t, err)
+}
+
+func TestApplyScenarioStaticProvider(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	// The static node set is added to the node sets of the scenario
+	descriptor := strings.Replace(scenarioDescriptor, "providers:\n", "providers:\n  metal:\n    kind: static\n", 1)
+	descriptor = strings.Replace(descriptor, "nodes:\n", `nodes:
+  metal:
+    provider:
+      name: metal
+    labels:
+      role: db
+    hooks:
+      create:
+        after:
+          - task: notify
+    hosts:
+      - name: db1
+        address: 10.0.0.1
+`, 1)
+	aM := ansible.CreateScriptedManager(ansible.Scenario{})
+	rC, clean := scenarioRuntimeContextWith(t, tester, aM, descriptor)
+	defer clean()
+
+	report, res := applyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+
+	// Only the node set of the provider component is created, the hooks of
+	// the static node set are run
+	assert.Len(t, aM.Played("provider", "create.yaml"), 1)
+	// The facts of the existing hosts are kept
+	assert.NotContains(t, aM.InvalidatedFacts(), "metal")
+	assert.Len(t, aM.Played("task", "notify.yaml"), 2)
+	assert.Len(t, aM.Played("stack", "deploy.yaml"), 1)
+
+	// The existing hosts are part of the inventory
+	if assert.NotNil(t, res) {
+		inv := res.(ApplyResult).Inventory
+		if assert.Contains(t, inv.Hosts, "db1") {
+			assert.Equal(t, "10.0.0.1", inv.Hosts["db1"].Address)
+			assert.Equal(t, "metal", inv.Hosts["db1"].NodeSet)
+			assert.Equal(t, map[string]string{"role": "db"}, inv.Hosts["db1"].Labels())
+		}
+	}
+
+	// Nothing is destroyed on the existing hosts
+	report, _ = destroyAction.Execute(rC)
+	assert.Nil(t, report.Error)
+	assert.Len(t, aM.Played("provider", "destroy.yaml"), 1)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ekara-platform/engine/ansible"
 	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
 )
 
 type (
@@ -29,10 +31,14 @@ type (
 		AppliedToName   string `json:",omitempty"`
 		Status          stepStatus
 		Context         stepInfo
-		FailureCause    failureCause `json:",omitempty"`
-		ErrorMessage    string       `json:",omitempty"`
-		ReadableMessage string       `json:",omitempty"`
-		RawContent      interface{}  `json:",omitempty"`
+		FailureCause    failureCause       `json:",omitempty"`
+		ErrorMessage    string             `json:",omitempty"`
+		ReadableMessage string             `json:",omitempty"`
+		RawContent      interface{}        `json:",omitempty"`
+		PlayOptions     *model.PlayOptions `json:",omitempty"`
+		Diff            string             `json:",omitempty"`
+		AnsibleConfig   string             `json:",omitempty"`
+		Discovery       ansible.Discovery  `json:",omitempty"`
 		ExecutionTime   time.Duration
 		error           error
 		cleanUp         Cleanup
@@ -143,6 +149,19 @@ func (sr *StepResults) MarshalJSON() (b []byte, e error) {
 	return
 }
 
+// hidden returns a copy of the step result where the sensitive values are hidden
+func (sr StepResult) hidden() StepResult {
+	sr.ErrorMessage = secret.Hide(sr.ErrorMessage)
+	sr.ReadableMessage = secret.Hide(sr.ReadableMessage)
+	if _, ok := sr.RawContent.(string); !ok && sr.RawContent != nil {
+		// The serialized contents are already hidden by failOn
+		sr.RawContent = secret.HideContent(sr.RawContent)
+	}
+	sr.Diff = secret.Hide(sr.Diff)
+	sr.AnsibleConfig = secret.Hide(sr.AnsibleConfig)
+	return sr
+}
+
 // MarshalJSON returns the json representation of a report step
 func (sr *StepResult) MarshalJSON() (b []byte, e error) {
 	temp := struct {
@@ -151,10 +170,14 @@ func (sr *StepResult) MarshalJSON() (b []byte, e error) {
 		AppliedToName   string `json:",omitempty"`
 		Status          stepStatus
 		Context         stepInfo
-		FailureCause    failureCause `json:",omitempty"`
-		ErrorMessage    string       `json:",omitempty"`
-		ReadableMessage string       `json:",omitempty"`
-		RawContent      interface{}  `json:",omitempty"`
+		FailureCause    failureCause       `json:",omitempty"`
+		ErrorMessage    string             `json:",omitempty"`
+		ReadableMessage string             `json:",omitempty"`
+		RawContent      interface{}        `json:",omitempty"`
+		PlayOptions     *model.PlayOptions `json:",omitempty"`
+		Diff            string             `json:",omitempty"`
+		AnsibleConfig   string             `json:",omitempty"`
+		Discovery       ansible.Discovery  `json:",omitempty"`
 		ExecutionTime   string
 	}{
 		StepName:        sr.StepName,
@@ -166,6 +189,10 @@ func (sr *StepResult) MarshalJSON() (b []byte, e error) {
 		ErrorMessage:    sr.ErrorMessage,
 		ReadableMessage: sr.ReadableMessage,
 		RawContent:      sr.RawContent,
+		PlayOptions:     sr.PlayOptions,
+		Diff:            sr.Diff,
+		AnsibleConfig:   sr.AnsibleConfig,
+		Discovery:       sr.Discovery,
 		ExecutionTime:   fmtDuration(sr.ExecutionTime),
 	}
 	b, e = json.MarshalIndent(&temp, "", "    ")
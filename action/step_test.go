@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -93,3 +95,35 @@ func TestTimeFormat(t *testing.T) {
 	assert.Equal(t, fmtDuration(3661001*time.Millisecond), "01h01m01s001")
 
 }
+
+func TestStepDiffReported(t *testing.T) {
+	sc := InitCodeStepResult("Deploying", nil, NoCleanUpRequired)
+	sc.Diff = "--- before\n+++ after"
+	b, err := sc.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"Diff": "--- before\n+++ after"`)
+}
+
+func TestStepPlayOptionsReported(t *testing.T) {
+	sc := InitCodeStepResult("Deploying", nil, NoCleanUpRequired)
+	sc.PlayOptions = &model.PlayOptions{Limit: "node-3"}
+	b, err := sc.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"Limit": "node-3"`)
+}
+
+func TestStepAnsibleConfigReported(t *testing.T) {
+	sc := InitCodeStepResult("Deploying", nil, NoCleanUpRequired)
+	sc.AnsibleConfig = "[defaults]\nforks = 50\n"
+	b, err := sc.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"AnsibleConfig": "[defaults]\nforks = 50\n"`)
+}
+
+func TestStepDiscoveryReported(t *testing.T) {
+	sc := InitCodeStepResult("Deploying", nil, NoCleanUpRequired)
+	sc.Discovery = ansible.Discovery{{Folder: "roles", Variable: "ANSIBLE_ROLES_PATH", Components: []string{"comp1"}, Paths: []string{"/components/comp1/roles"}}}
+	b, err := sc.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"Variable": "ANSIBLE_ROLES_PATH"`)
+}
@@ -2,24 +2,36 @@ package ansible
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/GroupePSA/componentizer"
 	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/ekara-platform/engine/util"
 )
 
 const (
-	taskPrefix           = "TASK ["
-	taskSuffix           = "]"
-	virtualEnvBinaryPath = "/opt/virtualenvs/%s/bin"
-	virtualEnvDefault    = "default"
+	taskPrefix = "TASK ["
+	taskSuffix = "]"
+
+	//DefaultInventoryTimeout is the maximum duration of the inventory generation,
+	//unless customized into the launch context
+	DefaultInventoryTimeout = 5 * time.Minute
+
+	//CheckModeExtraVar is the extra var telling the playbooks if they are launched in check mode
+	CheckModeExtraVar = "ekara_check_mode"
 )
 
 type (
@@ -32,17 +44,37 @@ type (
 		//      ctx: the context used to template components
 		//		playbook: the name of the playbook to launch
 		//		extraVars: the extra vars passed to the playbook
+		//		options: the options used to launch the playbook
 		//		envVars: the environment variables set before launching the playbook
 		//		fN: feedback notifier
 		//
-		Play(cr componentizer.UsableComponent, ctx componentizer.TemplateContext, playbook string, extraVars ExtraVars) (int, error)
+		Play(cr componentizer.UsableComponent, ctx componentizer.TemplateContext, playbook string, extraVars ExtraVars, options model.PlayOptions) (int, error)
 		// Inventory returns the current inventory of environment nodes
+		//
+		// The inventory is generated once and then reused until its invalidation.
 		Inventory(ctx componentizer.TemplateContext) (Inventory, error)
+		// InvalidateInventory discards the current inventory, to be called once
+		// nodes have been created or destroyed
+		InvalidateInventory()
+		// InvalidateFacts discards the facts cached for the hosts of the given
+		// node sets, to be called once they have been created or destroyed
+		InvalidateFacts(ctx componentizer.TemplateContext, nodeSets ...string) error
+		// ResetFacts discards the facts cached by the previous executions,
+		// unless the launch context keeps them, to be called once per execution
+		ResetFacts(ctx componentizer.TemplateContext) error
 	}
 
 	manager struct {
-		lC util.LaunchContext
-		cM componentizer.ComponentManager
+		lC        util.LaunchContext
+		cM        componentizer.ComponentManager
+		inventory *inventoryCache
+		facts     *factCache
+	}
+
+	// inventoryCache holds the inventory generated during the execution
+	inventoryCache struct {
+		inv *Inventory
+		mu  sync.Mutex
 	}
 
 	execChan struct {
@@ -54,20 +86,34 @@ type (
 
 //CreateAnsibleManager returns a new AnsibleManager, providing managed execution of
 //Ansible commands
-func CreateAnsibleManager(lC util.LaunchContext, cM componentizer.ComponentManager) Manager {
+//
+//The facts gathered on the hosts are cached into the given directory, by
+//environment, they are not cached if empty.
+func CreateAnsibleManager(lC util.LaunchContext, cM componentizer.ComponentManager, factsDir string) Manager {
 	return &manager{
-		lC: lC,
-		cM: cM,
+		lC:        lC,
+		cM:        cM,
+		inventory: &inventoryCache{},
+		facts:     &factCache{root: factsDir},
 	}
 }
 
-func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.TemplateContext, playbook string, extraVars ExtraVars) (int, error) {
+func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.TemplateContext, playbook string, extraVars ExtraVars, options model.PlayOptions) (int, error) {
 	ok, playBookPath := uc.ContainsFile(playbook)
 	if !ok {
 		return 0, fmt.Errorf("component \"%s\" does not contain playbook: %s", uc.Id(), playbook)
 	}
 	aM.lC.Log().Printf("Executing playbook %s from component %s", playBookPath.RelativePath(), playBookPath.Owner().Id())
 
+	// Virtualenv of the component
+	venv, err := aM.componentVirtualenv(uc, ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := venv.check("ansible-playbook"); err != nil {
+		return 0, err
+	}
+
 	var args = []string{playbook}
 
 	// Discovered modules
@@ -80,6 +126,18 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 	defer inventoryPaths.Release()
 	args = append(args, aM.buildInventoryArgs(inventoryPaths)...)
 
+	// Existing hosts of the static node sets
+	staticArgs, removeStatic, err := aM.buildStaticInventoryArgs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer removeStatic()
+	args = append(args, staticArgs...)
+
+	// Discovered roles, collections, plugins and libraries
+	discovered := aM.discover(ctx)
+	defer discovered.release()
+
 	// Component(s) env vars
 	allComps := []componentizer.ComponentRef{uc.Source()}
 	for _, mp := range modulePaths.Paths {
@@ -88,7 +146,28 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 	for _, mp := range inventoryPaths.Paths {
 		allComps = append(allComps, mp.Owner().Source())
 	}
-	env := aM.buildEnvVars(allComps...)
+	allComps = append(allComps, discovered.owners()...)
+	env := aM.buildEnvVars(venv, allComps...)
+	discovered.addTo(&env)
+	for _, dp := range discovered {
+		aM.lC.Log().Printf("Ansible %s directories: %s", dp.folder, dp.JoinAbsolutePaths(":"))
+	}
+	if err := writeDiscovery(extraVars, discovered.report()); err != nil {
+		return 0, err
+	}
+
+	// Ansible configuration, caching the facts
+	facts, err := aM.factCacheConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cfgPath, removeCfg, err := writeConfig(uc, ctx, extraVars, facts)
+	if err != nil {
+		return 0, err
+	}
+	defer removeCfg()
+	aM.lC.Log().Printf("Ansible configuration: %s", cfgPath)
+	env.add(configEnvVar, cfgPath)
 
 	// Extra vars
 	etxvs, err := aM.buildExtraVarsArgs(extraVars)
@@ -110,14 +189,24 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 	// SSH private key
 	args = append(args, "--private-key="+aM.lC.SSHPrivateKey())
 
+	// Launch options
+	args = append(args, buildPlayOptionsArgs(options)...)
+
+	// Check mode, only reporting the changes
+	checkMode := aM.lC.CheckMode()
+	if checkMode {
+		args = append(args, "--check", "--diff")
+	}
+
 	// Execution
-	log.Printf("Running the command \"ansible-playbook\" with arguments: %v", args)
-	eC, err := aM.exec(uc.RootPath(), "ansible-playbook", args, env)
+	log.Printf("Running the command \"ansible-playbook\" with arguments: %v", secret.Hide(fmt.Sprintf("%v", args)))
+	eC, err := aM.exec(context.Background(), uc.RootPath(), venv.binary("ansible-playbook"), args, env)
 	if err != nil {
 		return 0, err
 	}
 
 	storedLines := make([]string, 0)
+	diffLines := make([]string, 0)
 	// Read the logs as they come until a status code is returned
 	for {
 		select {
@@ -135,6 +224,9 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 			if strings.Index(sTrim, "TASK [") == 0 {
 				aM.lC.Feedback().Detail(sTrim[len(taskPrefix):strings.LastIndex(sTrim, taskSuffix)])
 			}
+			if checkMode {
+				diffLines = append(diffLines, outLine)
+			}
 			if aM.lC.Verbosity() > 0 {
 				aM.lC.Log().Println(outLine)
 			} else {
@@ -143,6 +235,11 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 			}
 		case status := <-eC.status:
 			aM.lC.Log().Printf("Playbook finished (%d)", status)
+			if checkMode {
+				if err := writeDiff(extraVars, extractDiff(diffLines)); err != nil {
+					return status, err
+				}
+			}
 			if status != 0 {
 				aM.lC.Log().Printf("Failed playbook output below")
 				for _, storeLine := range storedLines {
@@ -157,6 +254,38 @@ func (aM manager) Play(uc componentizer.UsableComponent, ctx componentizer.Templ
 }
 
 func (aM manager) Inventory(ctx componentizer.TemplateContext) (Inventory, error) {
+	aM.inventory.mu.Lock()
+	defer aM.inventory.mu.Unlock()
+	if aM.inventory.inv != nil {
+		aM.lC.Log().Printf("Reusing the inventory already generated")
+		return *aM.inventory.inv, nil
+	}
+	inv, err := aM.generateInventory(ctx)
+	if err != nil {
+		return inv, err
+	}
+	aM.inventory.inv = &inv
+	return inv, nil
+}
+
+func (aM manager) InvalidateInventory() {
+	aM.inventory.mu.Lock()
+	defer aM.inventory.mu.Unlock()
+	if aM.inventory.inv != nil {
+		aM.lC.Log().Printf("Discarding the inventory already generated")
+	}
+	aM.inventory.inv = nil
+}
+
+func (aM manager) inventoryTimeout() time.Duration {
+	if t := aM.lC.InventoryTimeout(); t > 0 {
+		return t
+	}
+	return DefaultInventoryTimeout
+}
+
+// generateInventory runs ansible-inventory on the inventory sources of the components
+func (aM manager) generateInventory(ctx componentizer.TemplateContext) (Inventory, error) {
 	res := Inventory{}
 	args := []string{"--list"}
 
@@ -165,21 +294,43 @@ func (aM manager) Inventory(ctx componentizer.TemplateContext) (Inventory, error
 	defer inventoryPaths.Release()
 	args = append(args, aM.buildInventoryArgs(inventoryPaths)...)
 
+	// Existing hosts of the static node sets
+	staticArgs, removeStatic, err := aM.buildStaticInventoryArgs(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer removeStatic()
+	args = append(args, staticArgs...)
+
+	// Virtualenv of the inventory sources
+	venv, err := aM.inventoryVirtualenv(inventoryPaths, ctx)
+	if err != nil {
+		return res, err
+	}
+	if err := venv.check("ansible-inventory"); err != nil {
+		return res, err
+	}
+
 	// Component(s) env vars
 	var allComps []componentizer.ComponentRef
 	for _, mp := range inventoryPaths.Paths {
 		allComps = append(allComps, mp.Owner().Source())
 	}
-	env := aM.buildEnvVars(allComps...)
+	env := aM.buildEnvVars(venv, allComps...)
 
-	log.Printf("Running the command \"ansible-inventory\" with arguments: %v", args)
-	eC, err := aM.exec(os.TempDir(), "ansible-inventory", args, env)
+	log.Printf("Running the command \"ansible-inventory\" with arguments: %v", secret.Hide(fmt.Sprintf("%v", args)))
+	timeout := aM.inventoryTimeout()
+	runCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	eC, err := aM.exec(runCtx, os.TempDir(), venv.binary("ansible-inventory"), args, env)
 	if err != nil {
 		return res, err
 	}
 
 	// Read the output until a status code is returned
 	sb := strings.Builder{}
+	errLines := make([]string, 0)
+	var status int
 	var finished bool
 	for !finished {
 		select {
@@ -188,22 +339,31 @@ func (aM manager) Inventory(ctx componentizer.TemplateContext) (Inventory, error
 				// log stderr directly
 				aM.lC.Log().Println(errLine)
 			}
+			// keep stderr to explain a failure
+			errLines = append(errLines, errLine)
 		case outLine := <-eC.out:
 			if aM.lC.Verbosity() > 0 {
 				// log stdout directly
 				aM.lC.Log().Println(outLine)
 			}
 			sb.WriteString(outLine)
-		case status := <-eC.status:
+		case status = <-eC.status:
 			aM.lC.Log().Printf("Inventory done (%d)", status)
 			finished = true
 		}
 	}
 
+	if runCtx.Err() == context.DeadlineExceeded {
+		return res, fmt.Errorf("the inventory generation did not complete within %s", timeout)
+	}
+	if status != 0 {
+		return res, fmt.Errorf("the inventory generation did not complete successfully (%d): %s", status, secret.Hide(strings.Join(errLines, "\n")))
+	}
+
 	// Parse the ansible output
 	err = res.UnmarshalAnsibleInventory([]byte(sb.String()))
 	if err != nil {
-		return res, err
+		return res, fmt.Errorf("invalid inventory returned by ansible-inventory: %s", err.Error())
 	}
 
 	return res, nil
@@ -229,11 +389,10 @@ func (aM manager) findInventoryPaths(ctx componentizer.TemplateContext) componen
 	return aM.cM.ContainsDirectory(util.InventoryModuleFolder, ctx)
 }
 
-func (aM manager) buildEnvVars(comps ...componentizer.ComponentRef) envVars {
+func (aM manager) buildEnvVars(venv virtualenv, comps ...componentizer.ComponentRef) envVars {
 	env := createEnvVars()
 	env.addDefaultOsVars()
-	// TODO: currently only the default virtual env is supported, later add logic to obtain a specific virtual env from the executed component
-	env.prependToVar("PATH", fmt.Sprintf(virtualEnvBinaryPath, virtualEnvDefault))
+	env.prependToVar("PATH", venv.binPath())
 	env.addProxy(aM.lC.Proxy())
 	for _, c := range comps {
 		if o, ok := c.(model.EnvVarsAware); ok {
@@ -242,7 +401,8 @@ func (aM manager) buildEnvVars(comps ...componentizer.ComponentRef) envVars {
 			}
 		}
 	}
-	aM.lC.Log().Printf("Ansible environment variables: %s", env.String())
+	secret.MarkSensitiveContent(env.Content)
+	aM.lC.Log().Printf("Ansible environment variables: %s", secret.Hide(env.String()))
 	return env
 }
 
@@ -263,31 +423,40 @@ func (aM manager) buildInventoryArgs(inventoryPaths componentizer.MatchingPaths)
 	return args
 }
 
+// buildExtraVarsArgs returns the arguments passing the extra vars to the
+// playbook, completed with the check mode without altering the given ones
 func (aM manager) buildExtraVarsArgs(extraVars ExtraVars) ([]string, error) {
-	var args []string
-	if !extraVars.Empty() {
-		s, e := extraVars.String()
-		if e != nil {
-			return args, e
-		}
-		aM.lC.Log().Printf("Ansible extra vars: %s", s)
-		args = append(args, "--extra-vars", s)
-	} else {
-		aM.lC.Log().Printf("No Ansible extra var")
+	content := make(map[string]interface{}, len(extraVars.Content)+1)
+	for k, v := range extraVars.Content {
+		content[k] = v
+	}
+	// The check mode is always defined, to be usable without default by the playbooks
+	content[CheckModeExtraVar] = aM.lC.CheckMode()
+	s, e := ExtraVars{Content: content}.String()
+	if e != nil {
+		return nil, e
 	}
-	return args, nil
+	secret.MarkSensitiveContent(content)
+	aM.lC.Log().Printf("Ansible extra vars: %s", secret.Hide(s))
+	return []string{"--extra-vars", s}, nil
 }
 
-func (aM manager) exec(dir string, ex string, args []string, envVars envVars) (execChan, error) {
+func (aM manager) exec(runCtx context.Context, dir string, ex string, args []string, envVars envVars) (execChan, error) {
 	eC := execChan{
 		out:    make(chan string),
 		err:    make(chan string),
 		status: make(chan int),
 	}
 
-	// TODO: currently only the default virtual env is supported, later add logic to obtain a specific virtual env from the executed component
-	cmd := exec.Command(fmt.Sprintf(virtualEnvBinaryPath+"/%s", virtualEnvDefault, ex), args...)
+	cmd := exec.Command(ex, args...)
 	cmd.Dir = dir
+	// A cancellable command runs into its own process group, killed as a whole
+	// on cancellation, otherwise the processes it forked would keep its outputs
+	// open and the command would never complete
+	cancellable := runCtx.Done() != nil
+	if cancellable {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
 	cmd.Env = []string{}
 	for k, v := range envVars.Content {
 		cmd.Env = append(cmd.Env, k+"="+v)
@@ -297,26 +466,47 @@ func (aM manager) exec(dir string, ex string, args []string, envVars envVars) (e
 	if err != nil {
 		return eC, err
 	}
-	logPipe(errReader, eC.err)
-
 	outReader, err := cmd.StdoutPipe()
 	if err != nil {
 		return eC, err
 	}
-	logPipe(outReader, eC.out)
 
 	err = cmd.Start()
 	if err != nil {
 		return eC, err
 	}
 
+	// The status is sent once all the output has been read
+	wg := &sync.WaitGroup{}
+	logPipe(errReader, eC.err, wg)
+	logPipe(outReader, eC.out, wg)
+
+	read := make(chan struct{})
+	if cancellable {
+		go func() {
+			select {
+			case <-runCtx.Done():
+				select {
+				case <-read:
+				default:
+					syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+				}
+			case <-read:
+			}
+		}()
+	}
+
 	go func() {
-		err = cmd.Wait()
+		wg.Wait()
+		close(read)
+		err := cmd.Wait()
 		if err != nil {
 			e, ok := err.(*exec.ExitError)
 			if ok {
 				s := e.Sys().(syscall.WaitStatus)
 				eC.status <- s.ExitStatus()
+			} else {
+				eC.status <- -1
 			}
 		} else {
 			eC.status <- 0
@@ -326,10 +516,74 @@ func (aM manager) exec(dir string, ex string, args []string, envVars envVars) (e
 	return eC, nil
 }
 
+// buildPlayOptionsArgs returns the arguments of ansible-playbook matching the options
+func buildPlayOptionsArgs(options model.PlayOptions) []string {
+	var args []string
+	if options.Limit != "" {
+		args = append(args, "--limit", options.Limit)
+	}
+	if len(options.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(options.Tags, ","))
+	}
+	if len(options.SkipTags) > 0 {
+		args = append(args, "--skip-tags", strings.Join(options.SkipTags, ","))
+	}
+	if options.Forks > 0 {
+		args = append(args, "--forks", strconv.Itoa(options.Forks))
+	}
+	if options.StartAtTask != "" {
+		args = append(args, "--start-at-task", options.StartAtTask)
+	}
+	return args
+}
+
+// extractDiff keeps, from the output of a playbook launched in check mode,
+// only the changes reported by the tasks, each preceded by the header of its
+// task
+func extractDiff(lines []string) []string {
+	res := make([]string, 0)
+	task := ""
+	inDiff := false
+	for _, line := range lines {
+		sTrim := strings.TrimSpace(line)
+		if inDiff {
+			if line != "" && strings.ContainsAny(line[:1], "+- @\\") {
+				res = append(res, line)
+				continue
+			}
+			inDiff = false
+		}
+		switch {
+		case strings.Index(sTrim, taskPrefix) == 0:
+			task = sTrim[:strings.LastIndex(sTrim, taskSuffix)+1]
+		case strings.HasPrefix(line, "--- "):
+			if task != "" {
+				res = append(res, task)
+				task = ""
+			}
+			res = append(res, line)
+			inDiff = true
+		}
+	}
+	return res
+}
+
+// writeDiff saves the changes reported by a playbook launched in check mode
+// into its output folder
+func writeDiff(extraVars ExtraVars, lines []string) error {
+	out, ok := extraVars.Content["output_dir"].(string)
+	if !ok {
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(out, util.DiffFileName), []byte(strings.Join(lines, "\n")), 0644)
+}
+
 // logPipe logs the given pipe, reader/closer on the given logger
-func logPipe(rc io.ReadCloser, ch chan string) {
+func logPipe(rc io.ReadCloser, ch chan string, wg *sync.WaitGroup) {
 	s := bufio.NewScanner(rc)
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		for s.Scan() {
 			ch <- s.Text()
 		}
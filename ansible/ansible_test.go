@@ -0,0 +1,99 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckModeExtraVar(t *testing.T) {
+	aM := CreateAnsibleManager(util.CreateMockLaunchContext(false), nil, "").(*manager)
+	exv := CreateExtraVars(util.CreateFolderPath("in"), util.CreateFolderPath("out"))
+	args, err := aM.buildExtraVarsArgs(exv)
+	assert.Nil(t, err)
+	if assert.Len(t, args, 2) {
+		assert.Equal(t, "--extra-vars", args[0])
+		assert.Contains(t, args[1], `"ekara_check_mode":false`)
+	}
+	// The extra vars of the caller are left untouched
+	assert.NotContains(t, exv.Content, CheckModeExtraVar)
+
+	// The check mode is passed even without other extra vars
+	args, err = aM.buildExtraVarsArgs(ExtraVars{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"--extra-vars", `{"ekara_check_mode":false}`}, args)
+}
+
+func TestWriteDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	exv := CreateExtraVars(util.CreateFolderPath("in"), util.CreateFolderPath(dir))
+	assert.Nil(t, writeDiff(exv, []string{"--- before", "+++ after"}))
+	b, err := ioutil.ReadFile(filepath.Join(dir, util.DiffFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, "--- before\n+++ after", string(b))
+
+	// Nothing is written without output folder
+	assert.Nil(t, writeDiff(CreateExtraVars(util.CreateFolderPath(""), util.CreateFolderPath("")), []string{"-"}))
+}
+
+func TestExtractDiff(t *testing.T) {
+	output := []string{
+		"PLAY [all] *********************",
+		"",
+		"TASK [Gathering Facts] *********",
+		"ok: [node-1]",
+		"",
+		"TASK [stack : write the configuration] *********",
+		"--- before: /etc/stack.conf",
+		"+++ after: /tmp/stack.conf",
+		"@@ -1,2 +1,2 @@",
+		" name: stack",
+		"-replicas: 1",
+		"+replicas: 2",
+		"",
+		"changed: [node-1]",
+		"",
+		"TASK [stack : check the service] *********",
+		"ok: [node-1]",
+		"",
+		"PLAY RECAP *********************",
+		"node-1 : ok=3 changed=1 unreachable=0 failed=0",
+	}
+	assert.Equal(t, []string{
+		"TASK [stack : write the configuration]",
+		"--- before: /etc/stack.conf",
+		"+++ after: /tmp/stack.conf",
+		"@@ -1,2 +1,2 @@",
+		" name: stack",
+		"-replicas: 1",
+		"+replicas: 2",
+	}, extractDiff(output))
+
+	// Without changes there isn't any diff
+	assert.Len(t, extractDiff([]string{"TASK [check] ***", "ok: [node-1]"}), 0)
+}
+
+func TestBuildPlayOptionsArgs(t *testing.T) {
+	assert.Len(t, buildPlayOptionsArgs(model.PlayOptions{}), 0)
+	assert.Equal(t, []string{
+		"--limit", "node-3",
+		"--tags", "config,deploy",
+		"--skip-tags", "restart",
+		"--forks", "10",
+		"--start-at-task", "Install packages",
+	}, buildPlayOptionsArgs(model.PlayOptions{
+		Limit:       "node-3",
+		Tags:        []string{"config", "deploy"},
+		SkipTags:    []string{"restart"},
+		Forks:       10,
+		StartAtTask: "Install packages",
+	}))
+}
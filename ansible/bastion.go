@@ -0,0 +1,75 @@
+package ansible
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ekara-platform/engine/model"
+)
+
+// sshCommonArgsVar is the ansible variable holding the extra arguments of the SSH connections
+const sshCommonArgsVar = "ansible_ssh_common_args"
+
+//BastionSSHArgs returns the SSH arguments connecting the hosts through the
+//bastion, authenticated with the given private key unless the bastion
+//specifies its own.
+//
+//The host key of the bastion is checked as specified by the SSH configuration
+//unless the bastion overrides it.
+func BastionSSHArgs(b model.Bastion, privateKey string) string {
+	cmd := []string{"ssh", "-W", "%h:%p", "-q"}
+	if b.HostKeyChecking != "" {
+		cmd = append(cmd, "-o", "StrictHostKeyChecking="+b.HostKeyChecking)
+	}
+	key := b.Key
+	if key == "" {
+		key = privateKey
+	}
+	if key != "" {
+		cmd = append(cmd, "-i", key)
+	}
+	if b.Port != 0 {
+		cmd = append(cmd, "-p", strconv.Itoa(b.Port))
+	}
+	dest := b.Host
+	if b.User != "" {
+		dest = b.User + "@" + b.Host
+	}
+	cmd = append(cmd, dest)
+	// The proxy command is expanded by ssh then run through a shell, the
+	// arguments are escaped accordingly, except the target of the forwarding
+	for i, arg := range cmd[4:] {
+		cmd[4+i] = shellQuote(strings.Replace(arg, "%", "%%", -1))
+	}
+	// The arguments are split by ansible before being passed to ssh
+	proxy := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(strings.Join(cmd, " "))
+	return fmt.Sprintf("-o ProxyCommand=\"%s\"", proxy)
+}
+
+// shellQuote quotes the argument for a POSIX shell, unless it only holds
+// characters without special meaning
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'"'"'`, -1) + "'"
+}
+
+// bastionVars returns the ansible variables connecting all the hosts through
+// the global bastion, and the ones of the groups named after the node sets
+// using another bastion
+func bastionVars(env model.Environment, privateKey string) (InventoryVars, map[string]InventoryVars) {
+	all := InventoryVars{}
+	if env.Bastion.IsDefined() {
+		all[sshCommonArgsVar] = BastionSSHArgs(env.Bastion, privateKey)
+	}
+	groups := make(map[string]InventoryVars)
+	for name, ns := range env.NodeSets {
+		b := ns.EffectiveBastion(env)
+		if b.IsDefined() && b != env.Bastion {
+			groups[name] = InventoryVars{sshCommonArgsVar: BastionSSHArgs(b, privateKey)}
+		}
+	}
+	return all, groups
+}
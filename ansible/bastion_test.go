@@ -0,0 +1,89 @@
+package ansible
+
+import (
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const bastionDescriptor = `
+name: bastion
+bastion:
+  host: bastion.example.com
+  user: jump
+providers:
+  metal:
+    kind: static
+    bastion:
+      host: 10.0.0.254
+      port: 2222
+      key: /keys/metal.pem
+  cloud:
+    kind: static
+nodes:
+  db:
+    provider:
+      name: metal
+    hosts:
+      - name: db1
+        address: 10.0.0.1
+  web:
+    provider:
+      name: cloud
+    hosts:
+      - name: web1
+        address: 10.0.1.1
+`
+
+func TestBastionSSHArgs(t *testing.T) {
+	assert.Equal(t,
+		`-o ProxyCommand="ssh -W %h:%p -q -i /keys/ssh.pem bastion.example.com"`,
+		BastionSSHArgs(model.Bastion{Host: "bastion.example.com"}, "/keys/ssh.pem"))
+	assert.Equal(t,
+		`-o ProxyCommand="ssh -W %h:%p -q -i /keys/jump.pem -p 2222 jump@10.0.0.254"`,
+		BastionSSHArgs(model.Bastion{Host: "10.0.0.254", User: "jump", Key: "/keys/jump.pem", Port: 2222}, "/keys/ssh.pem"))
+
+	// The host key checking is left to the SSH configuration unless specified
+	assert.Equal(t,
+		`-o ProxyCommand="ssh -W %h:%p -q -o StrictHostKeyChecking=yes bastion.example.com"`,
+		BastionSSHArgs(model.Bastion{Host: "bastion.example.com", HostKeyChecking: "yes"}, ""))
+
+	// The arguments are quoted for the shell running the proxy command
+	assert.Equal(t,
+		`-o ProxyCommand="ssh -W %h:%p -q -i '/my keys/it'\"'\"'s 100%%.pem' 'jump;rm@bastion.example.com'"`,
+		BastionSSHArgs(model.Bastion{Host: "bastion.example.com", User: "jump;rm"}, "/my keys/it's 100%.pem"))
+}
+
+func TestBastionInventory(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", bastionDescriptor)
+	tester.Init(repDesc.AsRepository("master"))
+
+	b, err := staticInventoryContent(tester.Env(), "/keys/ssh.pem")
+	assert.Nil(t, err)
+	content := struct {
+		All struct {
+			Vars     map[string]string
+			Children map[string]struct {
+				Vars map[string]string
+			}
+		}
+	}{}
+	assert.Nil(t, yaml.Unmarshal(b, &content))
+
+	// The global bastion applies to all the hosts
+	assert.Equal(t, `-o ProxyCommand="ssh -W %h:%p -q -i /keys/ssh.pem jump@bastion.example.com"`, content.All.Vars["ansible_ssh_common_args"])
+	// The node sets using another bastion override it
+	assert.Equal(t, `-o ProxyCommand="ssh -W %h:%p -q -i /keys/metal.pem -p 2222 jump@10.0.0.254"`, content.All.Children["db"].Vars["ansible_ssh_common_args"])
+	assert.Empty(t, content.All.Children["web"].Vars)
+
+	// Without static hosts the bastion settings are still passed to ansible
+	b, err = staticInventoryContent(model.Environment{Bastion: model.Bastion{Host: "bastion.example.com"}}, "")
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "ansible_ssh_common_args")
+}
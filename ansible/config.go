@@ -0,0 +1,156 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+)
+
+const (
+	//ConfigFileName is the name of the ansible configuration generated for each
+	//playbook, into its input folder
+	ConfigFileName = "ansible.cfg"
+
+	// configEnvVar is the environment variable locating the ansible configuration
+	configEnvVar = "ANSIBLE_CONFIG"
+)
+
+//DefaultConfig returns the ansible settings used by the engine unless
+//overridden by the descriptor or the components
+func DefaultConfig() model.AnsibleConfig {
+	return model.AnsibleConfig{
+		"defaults": {
+			"forks":               "20",
+			"retry_files_enabled": "False",
+		},
+		"ssh_connection": {
+			"ssh_args": "-o ControlMaster=auto -o ControlPersist=60s",
+		},
+	}
+}
+
+//ParseConfig reads the settings of an ansible.cfg file.
+//
+//Just like ansible, a setting may have no value, and the indented lines
+//following a setting continue its value.
+func ParseConfig(b []byte) (model.AnsibleConfig, error) {
+	res := model.AnsibleConfig{}
+	section, key := "", ""
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for i := 1; s.Scan(); i++ {
+		raw := s.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if key != "" && (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) {
+			res[section][key] += "\n" + line
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section, key = strings.TrimSpace(line[1:len(line)-1]), ""
+			if _, ok := res[section]; !ok {
+				res[section] = make(map[string]string)
+			}
+			continue
+		}
+		if section == "" {
+			return res, fmt.Errorf("invalid ansible configuration, line %d: setting outside of any section", i)
+		}
+		value := ""
+		key = line
+		if sep := strings.IndexAny(line, "=:"); sep >= 0 {
+			key, value = strings.TrimSpace(line[:sep]), strings.TrimSpace(line[sep+1:])
+		}
+		if key == "" {
+			return res, fmt.Errorf("invalid ansible configuration, line %d: %s", i, line)
+		}
+		res[section][key] = value
+	}
+	return res, s.Err()
+}
+
+// configContent returns the settings in the ansible.cfg format, sorted
+func configContent(cfg model.AnsibleConfig) []byte {
+	sections := make([]string, 0, len(cfg))
+	for section := range cfg {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	sb := strings.Builder{}
+	for i, section := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%s]\n", section))
+		keys := make([]string, 0, len(cfg[section]))
+		for k := range cfg[section] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// The following lines of a value are indented to continue it
+			sb.WriteString(fmt.Sprintf("%s = %s\n", k, strings.Replace(cfg[section][k], "\n", "\n    ", -1)))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// buildConfig returns the settings used to launch a playbook of the component:
+// the engine defaults completed with the given settings, overridden by the ones
+// of the descriptor, overridden by the ansible.cfg file of the component if any.
+//
+// The file of the component must be merged because ANSIBLE_CONFIG prevents
+// ansible from looking for it.
+func buildConfig(uc componentizer.UsableComponent, ctx componentizer.TemplateContext, engine model.AnsibleConfig) (model.AnsibleConfig, error) {
+	res := DefaultConfig().Override(engine)
+	if tplC, ok := ctx.(*model.TemplateContext); ok {
+		res = res.Override(tplC.Model.Ansible)
+	}
+	if ok, path := uc.ContainsFile(ConfigFileName); ok {
+		b, err := ioutil.ReadFile(path.AbsolutePath())
+		if err != nil {
+			return res, err
+		}
+		cfg, err := ParseConfig(b)
+		if err != nil {
+			return res, fmt.Errorf("component \"%s\": %s", uc.Id(), err.Error())
+		}
+		res = res.Override(cfg)
+	}
+	return res, nil
+}
+
+// writeConfig writes the ansible configuration of the playbook into its input
+// folder, or a temporary one, and returns its location along with the function
+// removing it when temporary
+func writeConfig(uc componentizer.UsableComponent, ctx componentizer.TemplateContext, extraVars ExtraVars, engine model.AnsibleConfig) (string, func(), error) {
+	noop := func() {}
+	cfg, err := buildConfig(uc, ctx, engine)
+	if err != nil {
+		return "", noop, err
+	}
+	dir, ok := extraVars.Content["input_dir"].(string)
+	remove := noop
+	if !ok {
+		dir, err = ioutil.TempDir("", "ekara-ansible")
+		if err != nil {
+			return "", noop, err
+		}
+		remove = func() { os.RemoveAll(dir) }
+	}
+	path := filepath.Join(dir, ConfigFileName)
+	if err := ioutil.WriteFile(path, configContent(cfg), 0644); err != nil {
+		remove()
+		return "", noop, err
+	}
+	return path, remove, nil
+}
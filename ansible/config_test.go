@@ -0,0 +1,155 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+# Comment
+[defaults]
+forks = 50
+host_key_checking: False
+
+; Another comment
+[ssh_connection]
+pipelining=True
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, model.AnsibleConfig{
+		"defaults":       {"forks": "50", "host_key_checking": "False"},
+		"ssh_connection": {"pipelining": "True"},
+	}, cfg)
+
+	_, err = ParseConfig([]byte("forks = 50\n"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid ansible configuration, line 1: setting outside of any section", err.Error())
+	}
+	_, err = ParseConfig([]byte("[defaults]\n= 50\n"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "invalid ansible configuration, line 2: = 50", err.Error())
+	}
+}
+
+func TestParseConfigContinuation(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+[defaults]
+callbacks_enabled = timer,
+    profile_tasks
+	# Comment
+    profile_roles
+forks = 50
+no_value
+
+[ssh_connection]
+ssh_args = -o ControlMaster=auto
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, model.AnsibleConfig{
+		"defaults":       {"callbacks_enabled": "timer,\nprofile_tasks\nprofile_roles", "forks": "50", "no_value": ""},
+		"ssh_connection": {"ssh_args": "-o ControlMaster=auto"},
+	}, cfg)
+
+	// The values spanning several lines can be read back
+	read, err := ParseConfig(configContent(cfg))
+	assert.Nil(t, err)
+	assert.Equal(t, cfg, read)
+
+	// An indented line right after a section is a setting
+	cfg, err = ParseConfig([]byte("[defaults]\n    forks = 50\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, model.AnsibleConfig{"defaults": {"forks": "50"}}, cfg)
+}
+
+func TestConfigContent(t *testing.T) {
+	assert.Equal(t, `[defaults]
+forks = 20
+retry_files_enabled = False
+
+[ssh_connection]
+ssh_args = -o ControlMaster=auto -o ControlPersist=60s
+`, string(configContent(DefaultConfig())))
+
+	// The generated content can be read back
+	cfg, err := ParseConfig(configContent(DefaultConfig()))
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultConfig(), cfg)
+}
+
+func TestWriteConfig(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("ansible.cfg", "[defaults]\ngathering = smart\nforks = 100\n")
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: config
+ekara:
+  components:
+    provider:
+      repository: provider
+ansible:
+  defaults:
+    forks: 50
+  ssh_connection:
+    pipelining: true
+providers:
+  p1:
+    component: provider
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: p1
+`)
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+
+	dir, err := ioutil.TempDir("", "config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The descriptor settings override the defaults
+	ud, err := tester.ComponentManager().Use(env.Platform.Self, tester.TemplateContext())
+	assert.Nil(t, err)
+	defer ud.Release()
+	path, remove, err := writeConfig(ud, tester.TemplateContext(), ExtraVars{Content: map[string]interface{}{"input_dir": dir}}, nil)
+	assert.Nil(t, err)
+	remove()
+	assert.Equal(t, filepath.Join(dir, ConfigFileName), path)
+	b, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	cfg, err := ParseConfig(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "50", cfg["defaults"]["forks"])
+	assert.Equal(t, "False", cfg["defaults"]["retry_files_enabled"])
+	assert.Equal(t, "true", cfg["ssh_connection"]["pipelining"])
+	assert.NotEmpty(t, cfg["ssh_connection"]["ssh_args"])
+
+	// The ansible.cfg of the component overrides the descriptor settings
+	up, err := tester.ComponentManager().Use(env.Providers["p1"], tester.TemplateContext())
+	assert.Nil(t, err)
+	defer up.Release()
+	cfg, err = buildConfig(up, tester.TemplateContext(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "100", cfg["defaults"]["forks"])
+	assert.Equal(t, "smart", cfg["defaults"]["gathering"])
+	assert.Equal(t, "true", cfg["ssh_connection"]["pipelining"])
+
+	// Without input folder the configuration is temporary
+	path, remove, err = writeConfig(up, tester.TemplateContext(), ExtraVars{Content: map[string]interface{}{}}, nil)
+	assert.Nil(t, err)
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+	remove()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
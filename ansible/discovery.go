@@ -0,0 +1,164 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/util"
+	"gopkg.in/yaml.v2"
+)
+
+//DiscoveryFileName is the name of the file listing the directories discovered
+//across the components for a playbook, into its input folder
+const DiscoveryFileName = "discovery.yaml"
+
+type (
+	//DiscoveredFolder lists the directories of a kind found into the components,
+	//by decreasing precedence
+	DiscoveredFolder struct {
+		// The name of the discovered directories
+		Folder string
+		// The environment variable passing the directories to ansible
+		Variable string
+		// The components holding the directories
+		Components []string
+		// The absolute paths of the directories
+		Paths []string
+	}
+
+	//Discovery lists the directories discovered across the components to launch a playbook
+	Discovery []DiscoveredFolder
+
+	// discoveredPaths holds the directories of a kind found into the components
+	discoveredPaths struct {
+		folder   string
+		variable string
+		componentizer.MatchingPaths
+	}
+
+	// discovered holds all the directories found into the components
+	discovered []discoveredPaths
+)
+
+// discoveredFolders maps the directories looked for into the components to
+// the environment variables passing them to ansible.
+//
+// The library directories complete the modules ones, passed with --module-path,
+// which ansible puts in front of ANSIBLE_LIBRARY.
+var discoveredFolders = []struct{ folder, variable string }{
+	{util.ComponentRolesFolder, "ANSIBLE_ROLES_PATH"},
+	{util.ComponentCollectionsFolder, "ANSIBLE_COLLECTIONS_PATHS"},
+	{util.ComponentFilterPluginsFolder, "ANSIBLE_FILTER_PLUGINS"},
+	{util.ComponentLookupPluginsFolder, "ANSIBLE_LOOKUP_PLUGINS"},
+	{util.ComponentCallbackPluginsFolder, "ANSIBLE_CALLBACK_PLUGINS"},
+	{util.ComponentLibraryFolder, "ANSIBLE_LIBRARY"},
+}
+
+// discover looks for the roles, collections, plugins and library directories
+// into all the components.
+//
+// The directories of the environment descriptor come first, followed by the
+// ones of its parents, from the closest to the furthest, so the content of a
+// component takes precedence over the one of the components it inherits from.
+func (aM manager) discover(ctx componentizer.TemplateContext) discovered {
+	res := make(discovered, 0, len(discoveredFolders))
+	for _, f := range discoveredFolders {
+		mp := aM.cM.ContainsDirectory(f.folder, ctx)
+		if mp.Count() == 0 {
+			continue
+		}
+		sortByPrecedence(mp.Paths, aM.cM.ComponentOrder())
+		res = append(res, discoveredPaths{folder: f.folder, variable: f.variable, MatchingPaths: mp})
+	}
+	return res
+}
+
+// sortByPrecedence sorts the paths in the reverse parsing order of their
+// components, the components out of the parsing order come last, by id
+func sortByPrecedence(paths []componentizer.MatchingPath, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, id := range order {
+		rank[id] = len(order) - 1 - i
+	}
+	rankOf := func(id string) int {
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(order)
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		ri, rj := rankOf(paths[i].Owner().Id()), rankOf(paths[j].Owner().Id())
+		if ri != rj {
+			return ri < rj
+		}
+		return paths[i].Owner().Id() < paths[j].Owner().Id()
+	})
+}
+
+// release deletes, if any, the templated components holding the directories
+func (d discovered) release() {
+	for _, dp := range d {
+		dp.Release()
+	}
+}
+
+// owners returns the components holding the directories
+func (d discovered) owners() []componentizer.ComponentRef {
+	var res []componentizer.ComponentRef
+	for _, dp := range d {
+		for _, p := range dp.Paths {
+			res = append(res, p.Owner().Source())
+		}
+	}
+	return res
+}
+
+// addTo puts the directories in front of the matching environment variables
+func (d discovered) addTo(env *envVars) {
+	for _, dp := range d {
+		env.prependToVar(dp.variable, dp.JoinAbsolutePaths(string(os.PathListSeparator)))
+	}
+}
+
+// report returns the list of the directories
+func (d discovered) report() Discovery {
+	res := make(Discovery, 0, len(d))
+	for _, dp := range d {
+		df := DiscoveredFolder{Folder: dp.folder, Variable: dp.variable}
+		for _, p := range dp.Paths {
+			df.Components = append(df.Components, p.Owner().Id())
+			df.Paths = append(df.Paths, p.AbsolutePath())
+		}
+		res = append(res, df)
+	}
+	return res
+}
+
+// writeDiscovery writes the list of the discovered directories into the input
+// folder of the playbook, if any
+func writeDiscovery(extraVars ExtraVars, d Discovery) error {
+	in, ok := extraVars.Content["input_dir"].(string)
+	if !ok || len(d) == 0 {
+		return nil
+	}
+	b, err := yaml.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(in, DiscoveryFileName), b, 0644)
+}
+
+//ReadDiscovery reads the list of the directories discovered for the playbook
+//having the given input folder
+func ReadDiscovery(inputDir string) (Discovery, error) {
+	res := Discovery{}
+	b, err := ioutil.ReadFile(filepath.Join(inputDir, DiscoveryFileName))
+	if err != nil {
+		return res, err
+	}
+	err = yaml.Unmarshal(b, &res)
+	return res, err
+}
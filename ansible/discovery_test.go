@@ -0,0 +1,92 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const discoveryDescriptor = `
+name: discovery
+ekara:
+  parent:
+    repository: parent
+  components:
+    comp1:
+      repository: comp1
+orchestrator:
+  component: comp1
+`
+
+func TestDiscover(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+	repParent := tester.CreateDirEmptyDesc("parent")
+	repParent.WriteFolderCommit("roles/common/tasks", "main.yaml", "---\n")
+	repParent.WriteFolderCommit("filter_plugins", "filters.py", "\n")
+	repComp1 := tester.CreateDirEmptyDesc("comp1")
+	repComp1.WriteFolderCommit("roles/common/tasks", "main.yaml", "---\n")
+	repComp1.WriteFolderCommit("library", "module.py", "\n")
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", discoveryDescriptor)
+	repDesc.WriteFolderCommit("roles/common/tasks", "main.yaml", "---\n")
+	repDesc.WriteFolderCommit("collections/ansible_collections/acme/tools", "galaxy.yml", "\n")
+	tester.Init(repDesc.AsRepository("master"))
+
+	aM := CreateAnsibleManager(util.CreateMockLaunchContext(false), tester.ComponentManager(), "").(*manager)
+	d := aM.discover(tester.TemplateContext())
+	defer d.release()
+
+	report := d.report()
+	if assert.Len(t, report, 4) {
+		// The directories are reported in a fixed order, whatever the components
+		assert.Equal(t, []string{"roles", "collections", "filter_plugins", "library"}, []string{report[0].Folder, report[1].Folder, report[2].Folder, report[3].Folder})
+		assert.Equal(t, "ANSIBLE_ROLES_PATH", report[0].Variable)
+		assert.Equal(t, "ANSIBLE_COLLECTIONS_PATHS", report[1].Variable)
+		assert.Equal(t, "ANSIBLE_FILTER_PLUGINS", report[2].Variable)
+		assert.Equal(t, "ANSIBLE_LIBRARY", report[3].Variable)
+
+		// The roles of the descriptor take precedence, followed by the ones of
+		// the components in the reverse parsing order
+		roles := report[0]
+		if assert.Equal(t, []string{model.MainComponentId, "comp1", "__main__parent"}, roles.Components) {
+			for _, p := range roles.Paths {
+				assert.Equal(t, "roles", filepath.Base(p))
+			}
+		}
+		assert.Equal(t, []string{"comp1"}, report[3].Components)
+	}
+
+	// The directories are put in front of the ones already set
+	env := createEnvVars()
+	env.add("ANSIBLE_ROLES_PATH", "/etc/ansible/roles")
+	d.addTo(&env)
+	assert.Equal(t, report[0].Paths[0]+":"+report[0].Paths[1]+":"+report[0].Paths[2]+":/etc/ansible/roles", env.Content["ANSIBLE_ROLES_PATH"])
+	assert.Equal(t, report[1].Paths[0], env.Content["ANSIBLE_COLLECTIONS_PATHS"])
+	assert.NotContains(t, env.Content, "ANSIBLE_LOOKUP_PLUGINS")
+}
+
+func TestWriteDiscovery(t *testing.T) {
+	in, err := ioutil.TempDir("", "discovery")
+	assert.Nil(t, err)
+	defer os.RemoveAll(in)
+
+	d := Discovery{{
+		Folder:     "roles",
+		Variable:   "ANSIBLE_ROLES_PATH",
+		Components: []string{"comp1"},
+		Paths:      []string{"/components/comp1/roles"},
+	}}
+	assert.Nil(t, writeDiscovery(ExtraVars{Content: map[string]interface{}{"input_dir": in}}, d))
+	read, err := ReadDiscovery(in)
+	assert.Nil(t, err)
+	assert.Equal(t, d, read)
+
+	// Nothing is written without input folder
+	assert.Nil(t, writeDiscovery(ExtraVars{}, d))
+}
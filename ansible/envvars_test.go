@@ -27,6 +27,13 @@ func TestUniqueEnvVars(t *testing.T) {
 }
 
 func TestOsEnvVars(t *testing.T) {
+	for _, k := range []string{"HOSTNAME", "PATH", "TERM", "HOME"} {
+		if v, ok := os.LookupEnv(k); ok {
+			defer os.Setenv(k, v)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
 	os.Setenv("HOSTNAME", "1")
 	os.Setenv("PATH", "2")
 	os.Setenv("TERM", "3")
@@ -0,0 +1,86 @@
+package ansible
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+)
+
+// executionFactsTimeout is how long the facts only shared within an execution
+// are kept, as a zero timeout would keep them forever
+const executionFactsTimeout = 24 * time.Hour
+
+// factCache holds the facts gathered on the hosts, by environment
+type factCache struct {
+	// root is the directory holding the facts of all the environments, no cache if empty
+	root string
+}
+
+// dir returns the directory holding the facts of the environment, scoped by its qualified name
+func (c *factCache) dir(ctx componentizer.TemplateContext) (string, bool) {
+	tplC, ok := ctx.(*model.TemplateContext)
+	if !ok || c.root == "" || tplC.Model.QName.Name == "" {
+		return "", false
+	}
+	return filepath.Join(c.root, tplC.Model.QName.String()), true
+}
+
+// factCacheConfig returns the settings sharing the facts gathered on the hosts
+// across all the playbooks of the execution, and across the executions while
+// they are not older than the TTL of the launch context
+func (aM manager) factCacheConfig(ctx componentizer.TemplateContext) (model.AnsibleConfig, error) {
+	dir, ok := aM.facts.dir(ctx)
+	if !ok {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	timeout := executionFactsTimeout
+	if ttl := aM.lC.FactCacheTTL(); ttl > 0 {
+		timeout = ttl
+	}
+	return model.AnsibleConfig{
+		"defaults": {
+			"gathering":               "smart",
+			"fact_caching":            "jsonfile",
+			"fact_caching_connection": dir,
+			"fact_caching_timeout":    strconv.Itoa(int(math.Ceil(timeout.Seconds()))),
+		},
+	}, nil
+}
+
+func (aM manager) ResetFacts(ctx componentizer.TemplateContext) error {
+	dir, ok := aM.facts.dir(ctx)
+	if !ok || aM.lC.FactCacheTTL() > 0 {
+		return nil
+	}
+	// The facts are only shared within the execution
+	return os.RemoveAll(dir)
+}
+
+func (aM manager) InvalidateFacts(ctx componentizer.TemplateContext, nodeSets ...string) error {
+	dir, ok := aM.facts.dir(ctx)
+	if !ok || len(nodeSets) == 0 {
+		return nil
+	}
+	inv, err := aM.Inventory(ctx)
+	if err != nil {
+		return err
+	}
+	inv = inv.Correlate(ctx.(*model.TemplateContext).Model)
+	for _, ns := range nodeSets {
+		for _, h := range inv.ByNodeSet(ns) {
+			// The jsonfile cache stores the facts of each host into a file named after it
+			if err := os.Remove(filepath.Join(dir, h.Name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// factsLaunchContext keeps the facts for the given duration
+type factsLaunchContext struct {
+	util.LaunchContext
+	ttl time.Duration
+}
+
+func (lC factsLaunchContext) FactCacheTTL() time.Duration {
+	return lC.ttl
+}
+
+const factsInventory = `{
+	"_meta": {
+		"hostvars": {
+			"host001": {"ekara": {"nodeset": "node1"}},
+			"host002": {"ekara": {"nodeset": "node2"}}
+		}
+	},
+	"all": {
+		"children": ["group001"]
+	},
+	"group001": {
+		"hosts": ["host001", "host002"]
+	}
+}`
+
+func TestFactCacheConfig(t *testing.T) {
+	aM, ctx, clean := inventoryManager(t, "", 0)
+	defer clean()
+
+	// Without directory the facts are not cached
+	cfg, err := aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, cfg)
+
+	root, err := ioutil.TempDir("", "facts")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "inventory")
+	assert.Nil(t, os.MkdirAll(dir, 0700))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "previous"), []byte("{}"), 0600))
+
+	// The cache is scoped to the environment, the facts expire even when only
+	// shared within the execution
+	aM.facts = &factCache{root: root}
+	cfg, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"gathering":               "smart",
+		"fact_caching":            "jsonfile",
+		"fact_caching_connection": dir,
+		"fact_caching_timeout":    "86400",
+	}, cfg["defaults"])
+
+	// The facts of the previous executions are dropped by each execution
+	assert.Nil(t, aM.ResetFacts(ctx))
+	_, err = os.Stat(filepath.Join(dir, "previous"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "host001"), []byte("{}"), 0600))
+	assert.Nil(t, aM.ResetFacts(ctx))
+	_, err = os.Stat(filepath.Join(dir, "host001"))
+	assert.True(t, os.IsNotExist(err))
+
+	// The facts gathered during the execution are kept
+	_, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "host001"), []byte("{}"), 0600))
+	_, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "host001"))
+	assert.Nil(t, err)
+
+	// The facts are kept across the executions with a TTL
+	aM.lC = factsLaunchContext{aM.lC, time.Hour}
+	assert.Nil(t, aM.ResetFacts(ctx))
+	cfg, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "3600", cfg["defaults"]["fact_caching_timeout"])
+	_, err = os.Stat(filepath.Join(dir, "host001"))
+	assert.Nil(t, err)
+
+	// A TTL shorter than a second still expires
+	aM.lC = factsLaunchContext{aM.lC, time.Millisecond}
+	cfg, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", cfg["defaults"]["fact_caching_timeout"])
+}
+
+func TestInvalidateFacts(t *testing.T) {
+	aM, ctx, clean := inventoryManager(t, "cat <<'EOF'\n"+factsInventory+"\nEOF\n", 0)
+	defer clean()
+
+	root, err := ioutil.TempDir("", "facts")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+	aM.facts = &factCache{root: root}
+	_, err = aM.factCacheConfig(ctx)
+	assert.Nil(t, err)
+	dir := filepath.Join(root, "inventory")
+	for _, h := range []string{"host001", "host002"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, h), []byte("{}"), 0600))
+	}
+
+	// Only the facts of the hosts of the node set are dropped
+	assert.Nil(t, aM.InvalidateFacts(ctx, "node1", "missing"))
+	_, err = os.Stat(filepath.Join(dir, "host001"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "host002"))
+	assert.Nil(t, err)
+
+	// The facts already dropped are ignored
+	assert.Nil(t, aM.InvalidateFacts(ctx, "node1"))
+}
@@ -3,6 +3,18 @@ package ansible
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+
+	"github.com/ekara-platform/engine/model"
+)
+
+const (
+	//NodeSetHostVar is the ekara host variable, set by the providers, holding the
+	//name of the node set of the host
+	NodeSetHostVar = "nodeset"
+	//LabelsHostVar is the ekara host variable, set by the providers, holding the
+	//labels of the host
+	LabelsHostVar = "labels"
 )
 
 type (
@@ -13,7 +25,17 @@ type (
 
 	Host struct {
 		Name string
-		Vars InventoryVars
+		// The address used to connect the host, if different from its name
+		Address string
+		// The user used to connect the host, if specified by the inventory
+		User string
+		// The SSH port used to connect the host, if specified by the inventory
+		Port int
+		// The node set holding the host, if known
+		NodeSet string
+		Vars    InventoryVars
+		// The labels of the node set holding the host
+		nodeSetLabels model.Labels
 	}
 
 	Group struct {
@@ -48,6 +70,10 @@ func (i *Inventory) UnmarshalAnsibleInventory(data []byte) error {
 					if allHostVars, ok := _meta[hostVars].(map[string]interface{}); ok {
 						if hostVars, ok := allHostVars[hostName].(map[string]interface{}); ok {
 							host.Vars = buildInventoryVariables(hostVars)
+							readConnection(&host, hostVars)
+							if ns, ok := host.Vars[NodeSetHostVar]; ok {
+								host.NodeSet = fmt.Sprintf("%v", ns)
+							}
 						}
 					}
 				}
@@ -80,7 +106,7 @@ func readGroup(root map[string]interface{}, name string, current map[string]inte
 	}
 
 	if grpVars, ok := current["vars"].(map[string]interface{}); ok {
-		group.Vars = buildInventoryVariables(grpVars)
+		group.Vars = buildGroupVariables(grpVars)
 	} else {
 		group.Vars = InventoryVars{}
 	}
@@ -100,6 +126,22 @@ func readGroup(root map[string]interface{}, name string, current map[string]inte
 	return res
 }
 
+// readConnection reads the connection variables of a host
+func readConnection(host *Host, src map[string]interface{}) {
+	if addr, ok := src["ansible_host"]; ok {
+		host.Address = fmt.Sprintf("%v", addr)
+	}
+	if user, ok := src["ansible_user"]; ok {
+		host.User = fmt.Sprintf("%v", user)
+	}
+	switch port := src["ansible_port"].(type) {
+	case float64:
+		host.Port = int(port)
+	case string:
+		host.Port, _ = strconv.Atoi(port)
+	}
+}
+
 func buildInventoryVariables(src map[string]interface{}) InventoryVars {
 	vars := InventoryVars{}
 	if ekara, ok := src["ekara"].(map[string]interface{}); ok {
@@ -109,3 +151,12 @@ func buildInventoryVariables(src map[string]interface{}) InventoryVars {
 	}
 	return vars
 }
+
+// buildGroupVariables keeps all the variables of a group
+func buildGroupVariables(src map[string]interface{}) InventoryVars {
+	vars := InventoryVars{}
+	for k, v := range src {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,130 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// inventoryLaunchContext uses the given virtualenvs and inventory timeout
+type inventoryLaunchContext struct {
+	util.LaunchContext
+	dir     string
+	timeout time.Duration
+}
+
+func (lC inventoryLaunchContext) VirtualenvDir() string {
+	return lC.dir
+}
+
+func (lC inventoryLaunchContext) InventoryTimeout() time.Duration {
+	return lC.timeout
+}
+
+// inventoryManager returns a manager running the given script in place of ansible-inventory
+func inventoryManager(t *testing.T, script string, timeout time.Duration) (*manager, *model.TemplateContext, func()) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", "name: inventory\n")
+	tester.Init(repDesc.AsRepository("master"))
+
+	dir, err := ioutil.TempDir("", "virtualenvs")
+	assert.Nil(t, err)
+	bin := filepath.Join(dir, virtualenvDefault, "bin")
+	assert.Nil(t, os.MkdirAll(bin, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(bin, "ansible-inventory"), []byte("#!/bin/sh\n"+script), 0755))
+
+	lC := inventoryLaunchContext{util.CreateMockLaunchContext(false), dir, timeout}
+	aM := CreateAnsibleManager(lC, tester.ComponentManager(), "").(*manager)
+	return aM, tester.TemplateContext().(*model.TemplateContext), func() {
+		tester.Clean()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestInventoryCached(t *testing.T) {
+	counter, err := ioutil.TempFile("", "inventory")
+	assert.Nil(t, err)
+	counter.Close()
+	defer os.Remove(counter.Name())
+
+	aM, ctx, clean := inventoryManager(t, "echo run >> "+counter.Name()+"\ncat <<'EOF'\n"+jsonInventory+"\nEOF\n", 0)
+	defer clean()
+
+	runs := func() int {
+		b, err := ioutil.ReadFile(counter.Name())
+		assert.Nil(t, err)
+		return strings.Count(string(b), "run")
+	}
+
+	inv, err := aM.Inventory(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, inv.Hosts, 4)
+	_, err = aM.Inventory(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, runs())
+
+	// The inventory is generated again once invalidated
+	aM.InvalidateInventory()
+	inv, err = aM.Inventory(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, inv.Hosts, 4)
+	assert.Equal(t, 2, runs())
+}
+
+func TestInventoryFailure(t *testing.T) {
+	aM, ctx, clean := inventoryManager(t, "echo '{}'\necho 'unable to parse the source' >&2\nexit 3\n", 0)
+	defer clean()
+
+	_, err := aM.Inventory(ctx)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "the inventory generation did not complete successfully (3): unable to parse the source", err.Error())
+	}
+
+	// A failure is not cached
+	aM.inventory.mu.Lock()
+	assert.Nil(t, aM.inventory.inv)
+	aM.inventory.mu.Unlock()
+}
+
+func TestInventoryInvalidOutput(t *testing.T) {
+	aM, ctx, clean := inventoryManager(t, "echo 'not json'\n", 0)
+	defer clean()
+
+	_, err := aM.Inventory(ctx)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "invalid inventory returned by ansible-inventory"))
+	}
+}
+
+func TestInventoryTimeout(t *testing.T) {
+	aM, ctx, clean := inventoryManager(t, "exec sleep 5\n", 200*time.Millisecond)
+	defer clean()
+
+	start := time.Now()
+	_, err := aM.Inventory(ctx)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "the inventory generation did not complete within 200ms", err.Error())
+	}
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestInventoryTimeoutForked(t *testing.T) {
+	// The forked process keeps the outputs open after the script is killed
+	aM, ctx, clean := inventoryManager(t, "sleep 5\n", 200*time.Millisecond)
+	defer clean()
+
+	start := time.Now()
+	_, err := aM.Inventory(ctx)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "the inventory generation did not complete within 200ms", err.Error())
+	}
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
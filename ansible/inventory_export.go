@@ -0,0 +1,375 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//InventorySelector selects the hosts of an inventory to export
+type InventorySelector struct {
+	// The groups holding the selected hosts, directly or through their children
+	Groups []string
+	// The labels of the node sets holding the selected hosts
+	Labels map[string]string
+}
+
+var invalidPrometheusLabel = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+//Labels returns the labels of the host, the ones reported by the inventory
+//overriding the ones of its node set
+func (h Host) Labels() map[string]string {
+	res := make(map[string]string)
+	for k, v := range h.nodeSetLabels {
+		res[k] = v
+	}
+	if labels, ok := h.Vars[LabelsHostVar].(map[string]interface{}); ok {
+		for k, v := range labels {
+			res[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return res
+}
+
+// address returns the address used to connect the host
+func (h Host) address() string {
+	if h.Address != "" {
+		return h.Address
+	}
+	return h.Name
+}
+
+// matches returns true if the host has all the given labels
+func (h Host) matches(labels map[string]string) bool {
+	hLabels := h.Labels()
+	for k, v := range labels {
+		if hv, ok := hLabels[k]; !ok || hv != v {
+			return false
+		}
+	}
+	return true
+}
+
+//Select returns the part of the inventory holding the hosts matching the selector.
+//
+//The groups without any selected host are dropped, an empty selector keeps the
+//whole inventory.
+func (i Inventory) Select(s InventorySelector) Inventory {
+	res := Inventory{
+		Hosts:  make(map[string]Host),
+		Groups: make(map[string]Group),
+	}
+
+	inGroups := make(map[string]bool)
+	all := i.allGroups()
+	for _, name := range s.Groups {
+		if g, ok := all[name]; ok {
+			for h := range g.allHosts() {
+				inGroups[h] = true
+			}
+		}
+	}
+	for name, h := range i.Hosts {
+		if len(s.Groups) > 0 && !inGroups[name] {
+			continue
+		}
+		if !h.matches(s.Labels) {
+			continue
+		}
+		res.Hosts[name] = h
+	}
+
+	for name, g := range i.Groups {
+		if sg, ok := g.selectHosts(res.Hosts); ok {
+			res.Groups[name] = sg
+		}
+	}
+	return res
+}
+
+// selectHosts returns the group keeping only the given hosts, and false if
+// none of them belong to the group or its children
+func (g Group) selectHosts(hosts map[string]Host) (Group, bool) {
+	res := Group{
+		Children: make(map[string]Group),
+		Hosts:    []string{},
+		Vars:     g.Vars,
+	}
+	for _, h := range g.Hosts {
+		if _, ok := hosts[h]; ok {
+			res.Hosts = append(res.Hosts, h)
+		}
+	}
+	for name, c := range g.Children {
+		if sc, ok := c.selectHosts(hosts); ok {
+			res.Children[name] = sc
+		}
+	}
+	return res, len(res.Hosts) > 0 || len(res.Children) > 0
+}
+
+// allHosts returns the hosts of the group and its children
+func (g Group) allHosts() map[string]bool {
+	res := make(map[string]bool)
+	for _, h := range g.Hosts {
+		res[h] = true
+	}
+	for _, c := range g.Children {
+		for h := range c.allHosts() {
+			res[h] = true
+		}
+	}
+	return res
+}
+
+// allGroups returns the groups of the inventory, including the nested ones, by name
+func (i Inventory) allGroups() map[string]Group {
+	res := make(map[string]Group)
+	var walk func(groups map[string]Group)
+	walk = func(groups map[string]Group) {
+		for name, g := range groups {
+			res[name] = g
+			walk(g.Children)
+		}
+	}
+	walk(i.Groups)
+	return res
+}
+
+// hostNames returns the names of the hosts, sorted
+func (i Inventory) hostNames() []string {
+	res := make([]string, 0, len(i.Hosts))
+	for name := range i.Hosts {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// connectionVars returns the ansible variables required to connect the host
+func (h Host) connectionVars() map[string]interface{} {
+	res := make(map[string]interface{})
+	if h.Address != "" {
+		res["ansible_host"] = h.Address
+	}
+	if h.User != "" {
+		res["ansible_user"] = h.User
+	}
+	if h.Port != 0 {
+		res["ansible_port"] = h.Port
+	}
+	return res
+}
+
+// hostVars returns all the ansible variables of the host, the ones reported
+// by the inventory being kept under the ekara key
+func (h Host) hostVars() map[string]interface{} {
+	res := h.connectionVars()
+	if len(h.Vars) > 0 {
+		res["ekara"] = map[string]interface{}(h.Vars)
+	}
+	return res
+}
+
+//ExportINI returns the inventory in the ansible INI format.
+//
+//The variables which are not plain strings are written in JSON.
+func (i Inventory) ExportINI() ([]byte, error) {
+	sb := strings.Builder{}
+	for _, name := range i.hostNames() {
+		line, err := iniLine(name, i.Hosts[name].hostVars())
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	groups := i.allGroups()
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g := groups[name]
+		sb.WriteString(fmt.Sprintf("\n[%s]\n", name))
+		hosts := append([]string{}, g.Hosts...)
+		sort.Strings(hosts)
+		for _, h := range hosts {
+			sb.WriteString(h + "\n")
+		}
+		if len(g.Vars) > 0 {
+			sb.WriteString(fmt.Sprintf("\n[%s:vars]\n", name))
+			for _, k := range sortedKeys(g.Vars) {
+				v, err := iniValue(g.Vars[k])
+				if err != nil {
+					return nil, err
+				}
+				sb.WriteString(k + "=" + v + "\n")
+			}
+		}
+		if len(g.Children) > 0 {
+			sb.WriteString(fmt.Sprintf("\n[%s:children]\n", name))
+			for _, c := range sortedKeys(g.Children) {
+				sb.WriteString(c + "\n")
+			}
+		}
+	}
+	return []byte(sb.String()), nil
+}
+
+// iniLine returns the host followed by its variables
+func iniLine(name string, vars map[string]interface{}) (string, error) {
+	res := name
+	for _, k := range sortedKeys(vars) {
+		v, err := iniValue(vars[k])
+		if err != nil {
+			return "", err
+		}
+		res = res + " " + k + "=" + v
+	}
+	return res, nil
+}
+
+// iniValue returns the value as written into an INI inventory
+func iniValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		if strings.ContainsAny(val, " \t'\"") {
+			return strconv.Quote(val), nil
+		}
+		return val, nil
+	case int, bool, float64:
+		return fmt.Sprintf("%v", val), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return "'" + string(b) + "'", nil
+}
+
+//ExportYAML returns the inventory in the ansible static YAML format
+func (i Inventory) ExportYAML() ([]byte, error) {
+	hosts := make(map[string]interface{})
+	for name, h := range i.Hosts {
+		hosts[name] = h.hostVars()
+	}
+	all := map[string]interface{}{
+		"hosts": hosts,
+	}
+	if len(i.Groups) > 0 {
+		all["children"] = yamlGroups(i.Groups)
+	}
+	return yaml.Marshal(map[string]interface{}{"all": all})
+}
+
+// yamlGroups returns the groups as written into a YAML inventory
+func yamlGroups(groups map[string]Group) map[string]interface{} {
+	res := make(map[string]interface{})
+	for name, g := range groups {
+		yg := make(map[string]interface{})
+		if len(g.Hosts) > 0 {
+			hosts := make(map[string]interface{})
+			for _, h := range g.Hosts {
+				hosts[h] = nil
+			}
+			yg["hosts"] = hosts
+		}
+		if len(g.Vars) > 0 {
+			yg["vars"] = map[string]interface{}(g.Vars)
+		}
+		if len(g.Children) > 0 {
+			yg["children"] = yamlGroups(g.Children)
+		}
+		res[name] = yg
+	}
+	return res
+}
+
+//ExportSSHConfig returns an OpenSSH client configuration connecting the hosts with
+//the given private key, and the given user unless the inventory specifies one
+func (i Inventory) ExportSSHConfig(user string, privateKey string) []byte {
+	sb := strings.Builder{}
+	for _, name := range i.hostNames() {
+		h := i.Hosts[name]
+		sb.WriteString(fmt.Sprintf("Host %s\n", name))
+		sb.WriteString(fmt.Sprintf("  HostName %s\n", h.address()))
+		if h.User != "" {
+			sb.WriteString(fmt.Sprintf("  User %s\n", h.User))
+		} else if user != "" {
+			sb.WriteString(fmt.Sprintf("  User %s\n", user))
+		}
+		if h.Port != 0 {
+			sb.WriteString(fmt.Sprintf("  Port %d\n", h.Port))
+		}
+		if privateKey != "" {
+			sb.WriteString(fmt.Sprintf("  IdentityFile %s\n", privateKey))
+			sb.WriteString("  IdentitiesOnly yes\n")
+		}
+		sb.WriteString("\n")
+	}
+	return []byte(sb.String())
+}
+
+//ExportHosts returns an /etc/hosts fragment resolving the hosts having an address
+func (i Inventory) ExportHosts() []byte {
+	sb := strings.Builder{}
+	for _, name := range i.hostNames() {
+		h := i.Hosts[name]
+		if h.Address == "" || h.Address == name {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s\t%s\n", h.Address, name))
+	}
+	return []byte(sb.String())
+}
+
+//ExportPrometheus returns the hosts as Prometheus file_sd targets on the given port,
+//labelled with their name and the labels of their node set
+func (i Inventory) ExportPrometheus(port int) ([]byte, error) {
+	type target struct {
+		Targets []string          `json:"targets"`
+		Labels  map[string]string `json:"labels"`
+	}
+	res := make([]target, 0, len(i.Hosts))
+	for _, name := range i.hostNames() {
+		h := i.Hosts[name]
+		addr := h.address()
+		if port != 0 {
+			addr = fmt.Sprintf("%s:%d", addr, port)
+		}
+		labels := map[string]string{"ekara_host": name}
+		for k, v := range h.Labels() {
+			labels[invalidPrometheusLabel.ReplaceAllString(k, "_")] = v
+		}
+		res = append(res, target{Targets: []string{addr}, Labels: labels})
+	}
+	return json.MarshalIndent(res, "", "  ")
+}
+
+// sortedKeys returns the keys of the map, sorted
+func sortedKeys(m interface{}) []string {
+	res := make([]string, 0)
+	switch mt := m.(type) {
+	case map[string]interface{}:
+		for k := range mt {
+			res = append(res, k)
+		}
+	case InventoryVars:
+		for k := range mt {
+			res = append(res, k)
+		}
+	case map[string]Group:
+		for k := range mt {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
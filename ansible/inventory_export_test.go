@@ -0,0 +1,153 @@
+package ansible
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const jsonExportInventory = `{
+		"_meta": {
+			"hostvars": {
+				"host001": {
+					"ansible_host": "10.0.0.1",
+					"ansible_user": "centos",
+					"ekara": {
+						"labels": {"role": "web"}
+					}
+				},
+				"host002": {
+					"ansible_host": "10.0.0.2",
+					"ansible_port": 2222,
+					"ekara": {
+						"labels": {"role": "db", "node.tier": "back"}
+					}
+				}
+			}
+		},
+		"all": {
+			"children": ["nodes"]
+		},
+		"nodes": {
+			"children": ["web"],
+			"hosts": ["host002"],
+			"vars": {
+				"region": "eu west"
+			}
+		},
+		"web": {
+			"hosts": ["host001"]
+		}
+	}`
+
+func exportInventory(t *testing.T) Inventory {
+	inv := Inventory{}
+	assert.Nil(t, inv.UnmarshalAnsibleInventory([]byte(jsonExportInventory)))
+	return inv
+}
+
+func TestInventoryConnection(t *testing.T) {
+	inv := exportInventory(t)
+	assert.Equal(t, "10.0.0.1", inv.Hosts["host001"].Address)
+	assert.Equal(t, "centos", inv.Hosts["host001"].User)
+	assert.Equal(t, 2222, inv.Hosts["host002"].Port)
+	assert.Equal(t, map[string]string{"role": "web"}, inv.Hosts["host001"].Labels())
+}
+
+func TestInventorySelect(t *testing.T) {
+	inv := exportInventory(t)
+
+	assert.Len(t, inv.Select(InventorySelector{}).Hosts, 2)
+
+	web := inv.Select(InventorySelector{Groups: []string{"web"}})
+	assert.Contains(t, web.Hosts, "host001")
+	assert.Len(t, web.Hosts, 1)
+	// The parents of the selected hosts are kept without the other hosts
+	if assert.Contains(t, web.Groups, "nodes") {
+		assert.Equal(t, []string{}, web.Groups["nodes"].Hosts)
+		assert.Contains(t, web.Groups["nodes"].Children, "web")
+	}
+
+	db := inv.Select(InventorySelector{Labels: map[string]string{"role": "db"}})
+	assert.Contains(t, db.Hosts, "host002")
+	assert.Len(t, db.Hosts, 1)
+	assert.NotContains(t, db.Groups["nodes"].Children, "web")
+
+	assert.Len(t, inv.Select(InventorySelector{Groups: []string{"web"}, Labels: map[string]string{"role": "db"}}).Hosts, 0)
+	assert.Len(t, inv.Select(InventorySelector{Groups: []string{"unknown"}}).Hosts, 0)
+}
+
+func TestExportINI(t *testing.T) {
+	b, err := exportInventory(t).ExportINI()
+	assert.Nil(t, err)
+	assert.Equal(t, `host001 ansible_host=10.0.0.1 ansible_user=centos ekara='{"labels":{"role":"web"}}'
+host002 ansible_host=10.0.0.2 ansible_port=2222 ekara='{"labels":{"node.tier":"back","role":"db"}}'
+
+[nodes]
+host002
+
+[nodes:vars]
+region="eu west"
+
+[nodes:children]
+web
+
+[web]
+host001
+`, string(b))
+}
+
+func TestExportYAML(t *testing.T) {
+	b, err := exportInventory(t).ExportYAML()
+	assert.Nil(t, err)
+
+	res := make(map[string]map[string]interface{})
+	assert.Nil(t, yaml.Unmarshal(b, &res))
+	hosts := res["all"]["hosts"].(map[interface{}]interface{})
+	assert.Equal(t, "10.0.0.1", hosts["host001"].(map[interface{}]interface{})["ansible_host"])
+	nodes := res["all"]["children"].(map[interface{}]interface{})["nodes"].(map[interface{}]interface{})
+	assert.Contains(t, nodes["hosts"], "host002")
+	assert.Equal(t, "eu west", nodes["vars"].(map[interface{}]interface{})["region"])
+	assert.Contains(t, nodes["children"], "web")
+}
+
+func TestExportSSHConfig(t *testing.T) {
+	b := exportInventory(t).ExportSSHConfig("ekara", "/keys/ssh.pem")
+	assert.Equal(t, `Host host001
+  HostName 10.0.0.1
+  User centos
+  IdentityFile /keys/ssh.pem
+  IdentitiesOnly yes
+
+Host host002
+  HostName 10.0.0.2
+  User ekara
+  Port 2222
+  IdentityFile /keys/ssh.pem
+  IdentitiesOnly yes
+
+`, string(b))
+}
+
+func TestExportHosts(t *testing.T) {
+	b := exportInventory(t).ExportHosts()
+	assert.Equal(t, "10.0.0.1\thost001\n10.0.0.2\thost002\n", string(b))
+}
+
+func TestExportPrometheus(t *testing.T) {
+	b, err := exportInventory(t).ExportPrometheus(9100)
+	assert.Nil(t, err)
+
+	var res []struct {
+		Targets []string
+		Labels  map[string]string
+	}
+	assert.Nil(t, json.Unmarshal(b, &res))
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, []string{"10.0.0.1:9100"}, res[0].Targets)
+		assert.Equal(t, map[string]string{"ekara_host": "host001", "role": "web"}, res[0].Labels)
+		assert.Equal(t, map[string]string{"ekara_host": "host002", "role": "db", "node_tier": "back"}, res[1].Labels)
+	}
+}
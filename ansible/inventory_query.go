@@ -0,0 +1,99 @@
+package ansible
+
+import (
+	"github.com/ekara-platform/engine/model"
+)
+
+//Correlate returns the inventory with its hosts linked to the node sets of the
+//environment.
+//
+//A host belongs to the node set named by its ekara "nodeset" variable or, if the
+//provider doesn't set it, to the node set named as one of its groups. The hosts
+//inherit the labels of their node set.
+func (i Inventory) Correlate(env model.Environment) Inventory {
+	res := Inventory{
+		Hosts:  make(map[string]Host, len(i.Hosts)),
+		Groups: i.Groups,
+	}
+	groups := i.allGroups()
+	for name, h := range i.Hosts {
+		if h.NodeSet == "" {
+			for nsName := range env.NodeSets {
+				if g, ok := groups[nsName]; ok && g.allHosts()[name] {
+					h.NodeSet = nsName
+					break
+				}
+			}
+		}
+		if ns, ok := env.NodeSets[h.NodeSet]; ok {
+			h.nodeSetLabels = ns.Labels
+		}
+		res.Hosts[name] = h
+	}
+	return res
+}
+
+//ByNodeSet returns the hosts of the given node set, sorted by name
+func (i Inventory) ByNodeSet(name string) []Host {
+	res := make([]Host, 0)
+	for _, n := range i.hostNames() {
+		if h := i.Hosts[n]; h.NodeSet == name {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+//BySelector returns the hosts whose labels match the selector, sorted by name
+func (i Inventory) BySelector(selector model.LabelSelector) []Host {
+	res := make([]Host, 0)
+	for _, n := range i.hostNames() {
+		if h := i.Hosts[n]; selector.Matches(h.Labels()) {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+//AsInventoryHost returns the host as exposed to the templates
+func (h Host) AsInventoryHost() model.InventoryHost {
+	vars := make(map[string]interface{}, len(h.Vars))
+	for k, v := range h.Vars {
+		vars[k] = v
+	}
+	return model.InventoryHost{
+		Name:    h.Name,
+		Address: h.address(),
+		NodeSet: h.NodeSet,
+		Labels:  h.Labels(),
+		Vars:    vars,
+	}
+}
+
+// asInventoryHosts converts the hosts as exposed to the templates
+func asInventoryHosts(hosts []Host) []model.InventoryHost {
+	res := make([]model.InventoryHost, 0, len(hosts))
+	for _, h := range hosts {
+		res = append(res, h.AsInventoryHost())
+	}
+	return res
+}
+
+//TemplateInventory exposes the inventory to the templates
+type TemplateInventory struct {
+	Inventory Inventory
+}
+
+//ByNodeSet implements model.Inventory
+func (t TemplateInventory) ByNodeSet(name string) ([]model.InventoryHost, error) {
+	return asInventoryHosts(t.Inventory.ByNodeSet(name)), nil
+}
+
+//BySelector implements model.Inventory
+func (t TemplateInventory) BySelector(selector string) ([]model.InventoryHost, error) {
+	s, err := model.ParseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return asInventoryHosts(t.Inventory.BySelector(s)), nil
+}
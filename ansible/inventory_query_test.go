@@ -0,0 +1,114 @@
+package ansible
+
+import (
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonNodeSetInventory = `{
+		"_meta": {
+			"hostvars": {
+				"host001": {
+					"ansible_host": "10.0.0.1",
+					"ekara": {
+						"nodeset": "workers",
+						"labels": {"role": "web"}
+					}
+				},
+				"host002": {
+					"ansible_host": "10.0.0.2",
+					"ekara": {
+						"nodeset": "workers"
+					}
+				},
+				"host003": {
+					"ansible_host": "10.0.0.3"
+				}
+			}
+		},
+		"all": {
+			"children": ["workers", "managers"]
+		},
+		"workers": {
+			"hosts": ["host001", "host002"]
+		},
+		"managers": {
+			"hosts": ["host003"]
+		}
+	}`
+
+func nodeSetInventory(t *testing.T) Inventory {
+	inv := Inventory{}
+	assert.Nil(t, inv.UnmarshalAnsibleInventory([]byte(jsonNodeSetInventory)))
+	return inv.Correlate(model.Environment{
+		NodeSets: model.NodeSets{
+			"workers":  {Name: "workers", Labels: model.Labels{"role": "worker", "tier": "back"}},
+			"managers": {Name: "managers", Labels: model.Labels{"role": "manager"}},
+		},
+	})
+}
+
+func TestInventoryByNodeSet(t *testing.T) {
+	inv := nodeSetInventory(t)
+
+	workers := inv.ByNodeSet("workers")
+	if assert.Len(t, workers, 2) {
+		assert.Equal(t, "host001", workers[0].Name)
+		assert.Equal(t, "host002", workers[1].Name)
+	}
+
+	// Correlated through the group when the provider doesn't set the node set
+	managers := inv.ByNodeSet("managers")
+	if assert.Len(t, managers, 1) {
+		assert.Equal(t, "host003", managers[0].Name)
+	}
+
+	assert.Len(t, inv.ByNodeSet("unknown"), 0)
+}
+
+func TestInventoryLabels(t *testing.T) {
+	inv := nodeSetInventory(t)
+	// The labels reported by the provider override the ones of the node set
+	assert.Equal(t, map[string]string{"role": "web", "tier": "back"}, inv.Hosts["host001"].Labels())
+	assert.Equal(t, map[string]string{"role": "worker", "tier": "back"}, inv.Hosts["host002"].Labels())
+}
+
+func TestInventoryBySelector(t *testing.T) {
+	inv := nodeSetInventory(t)
+
+	names := func(selector string) []string {
+		s, err := model.ParseLabelSelector(selector)
+		assert.Nil(t, err)
+		res := make([]string, 0)
+		for _, h := range inv.BySelector(s) {
+			res = append(res, h.Name)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"host001", "host002", "host003"}, names(""))
+	assert.Equal(t, []string{"host001", "host002"}, names("tier=back"))
+	assert.Equal(t, []string{"host002", "host003"}, names("role in (worker, manager)"))
+	assert.Equal(t, []string{"host003"}, names("!tier"))
+	assert.Equal(t, []string{"host001"}, names("tier, role notin (worker)"))
+}
+
+func TestTemplateInventory(t *testing.T) {
+	tI := TemplateInventory{Inventory: nodeSetInventory(t)}
+
+	hosts, err := tI.ByNodeSet("workers")
+	assert.Nil(t, err)
+	if assert.Len(t, hosts, 2) {
+		assert.Equal(t, model.InventoryHost{
+			Name:    "host001",
+			Address: "10.0.0.1",
+			NodeSet: "workers",
+			Labels:  model.Labels{"role": "web", "tier": "back"},
+			Vars:    map[string]interface{}{"nodeset": "workers", "labels": map[string]interface{}{"role": "web"}},
+		}, hosts[0])
+	}
+
+	_, err = tI.BySelector("role in")
+	assert.NotNil(t, err)
+}
@@ -56,3 +56,27 @@ func TestParseAndMarshal(t *testing.T) {
 	assert.Nil(t, err)
 	fmt.Println(string(b))
 }
+
+func TestParseGroupVars(t *testing.T) {
+	inv := Inventory{}
+	err := inv.UnmarshalAnsibleInventory([]byte(`{
+		"all": {
+			"children": ["group001"]
+		},
+		"group001": {
+			"hosts": ["host001"],
+			"vars": {
+				"ansible_user": "centos",
+				"ekara": {
+					"var1": true
+				}
+			}
+		}
+	}`))
+	assert.Nil(t, err)
+	if assert.Contains(t, inv.Groups, "group001") {
+		vars := inv.Groups["group001"].Vars
+		assert.Equal(t, "centos", vars["ansible_user"])
+		assert.Equal(t, map[string]interface{}{"var1": true}, vars["ekara"])
+	}
+}
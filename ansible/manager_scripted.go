@@ -0,0 +1,261 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	//ScriptedOutcome is the programmed outcome of the playbooks matching a component and a playbook
+	ScriptedOutcome struct {
+		// The component holding the playbook, any component if empty
+		Component string `yaml:",omitempty"`
+		// The playbook, any playbook if empty
+		Playbook string `yaml:",omitempty"`
+		// The returned exit code, a non-zero code makes the playbook fail
+		Code int `yaml:",omitempty"`
+		// The returned error message, a generic one is returned for a non-zero code without message
+		Error string `yaml:",omitempty"`
+		// The content written into the output.yaml of the playbook
+		Output string `yaml:",omitempty"`
+		// The changes reported by the playbook, written into its output folder
+		Diff string `yaml:",omitempty"`
+	}
+
+	//Scenario defines the behavior of a ScriptedManager
+	Scenario struct {
+		// The programmed outcomes, the first one matching a launched playbook applies.
+		// The playbooks without matching outcome succeed.
+		Outcomes []ScriptedOutcome `yaml:",omitempty"`
+		// The returned inventory
+		Inventory Inventory `yaml:",omitempty"`
+		// The error message returned instead of the inventory
+		InventoryError string `yaml:"inventoryError,omitempty"`
+	}
+
+	//PlayCall records a playbook launched through a ScriptedManager
+	PlayCall struct {
+		// The component holding the playbook
+		Component string
+		// The launched playbook
+		Playbook string
+		// The extra vars passed to the playbook
+		ExtraVars map[string]interface{}
+		// The content of the params.yaml passed to the playbook, if any
+		Params string
+		// The options used to launch the playbook
+		Options model.PlayOptions
+		// The content of the ansible.cfg generated for the playbook, if any
+		Config string
+		// The number of invalidations of the inventory before launching the playbook
+		Invalidations int
+		// The node sets whose facts have been invalidated before launching the playbook
+		InvalidatedFacts []string
+	}
+
+	//ScriptedManager simulates the execution of the playbooks following a
+	//scenario, for testing purposes
+	ScriptedManager struct {
+		scenario      Scenario
+		calls         []PlayCall
+		invalidations int
+		factNodeSets  []string
+		factResets    int
+		mu            sync.Mutex
+	}
+)
+
+//CreateScriptedManager returns a manager simulating the execution of the playbooks
+func CreateScriptedManager(scenario Scenario) *ScriptedManager {
+	return &ScriptedManager{
+		scenario: scenario,
+		calls:    make([]PlayCall, 0),
+	}
+}
+
+//ParseScenario reads a scenario from its yaml definition
+func ParseScenario(b []byte) (Scenario, error) {
+	s := Scenario{}
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return s, fmt.Errorf("invalid scenario: %s", err.Error())
+	}
+	return s, nil
+}
+
+//Play records the playbook and returns the outcome programmed into the scenario
+func (m *ScriptedManager) Play(uc componentizer.UsableComponent, ctx componentizer.TemplateContext, playbook string, extraVars ExtraVars, options model.PlayOptions) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ok, _ := uc.ContainsFile(playbook); !ok {
+		return 0, fmt.Errorf("component \"%s\" does not contain playbook: %s", uc.Id(), playbook)
+	}
+
+	call := PlayCall{
+		Component:     uc.Id(),
+		Playbook:      playbook,
+		ExtraVars:     make(map[string]interface{}, len(extraVars.Content)),
+		Options:       options,
+		Invalidations: m.invalidations,
+	}
+	call.InvalidatedFacts = append(call.InvalidatedFacts, m.factNodeSets...)
+	for k, v := range extraVars.Content {
+		call.ExtraVars[k] = v
+	}
+	if in, ok := extraVars.Content["input_dir"].(string); ok {
+		if b, err := ioutil.ReadFile(filepath.Join(in, util.ParamYamlFileName)); err == nil {
+			call.Params = string(b)
+		}
+		// The ansible configuration is generated as it would be for ansible
+		path, _, err := writeConfig(uc, ctx, extraVars, nil)
+		if err != nil {
+			return 0, err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		call.Config = string(b)
+	}
+	m.calls = append(m.calls, call)
+
+	outcome, ok := m.outcome(uc.Id(), playbook)
+	if !ok {
+		return 0, nil
+	}
+	if outcome.Output != "" {
+		out, ok := extraVars.Content["output_dir"].(string)
+		if !ok {
+			return 0, fmt.Errorf("no output directory to write the output of the playbook %s", playbook)
+		}
+		if err := os.MkdirAll(out, 0700); err != nil {
+			return 0, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(out, util.OutputYamlFileName), []byte(outcome.Output), 0644); err != nil {
+			return 0, err
+		}
+	}
+	if outcome.Diff != "" {
+		if err := writeDiff(extraVars, strings.Split(outcome.Diff, "\n")); err != nil {
+			return 0, err
+		}
+	}
+	if outcome.Error != "" {
+		return outcome.Code, errors.New(outcome.Error)
+	}
+	if outcome.Code != 0 {
+		return outcome.Code, fmt.Errorf("playbook did not complete successfully (%d), check the logs for details", outcome.Code)
+	}
+	return 0, nil
+}
+
+//Inventory returns the inventory defined into the scenario, completed with the
+//existing hosts of the static node sets
+func (m *ScriptedManager) Inventory(ctx componentizer.TemplateContext) (Inventory, error) {
+	if m.scenario.InventoryError != "" {
+		return Inventory{}, errors.New(m.scenario.InventoryError)
+	}
+	tplC, ok := ctx.(*model.TemplateContext)
+	if !ok {
+		return m.scenario.Inventory, nil
+	}
+	res := Inventory{
+		Hosts:  make(map[string]Host),
+		Groups: make(map[string]Group),
+	}
+	static := StaticInventory(tplC.Model)
+	for _, inv := range []Inventory{m.scenario.Inventory, static} {
+		for k, h := range inv.Hosts {
+			res.Hosts[k] = h
+		}
+		for k, g := range inv.Groups {
+			res.Groups[k] = g
+		}
+	}
+	return res, nil
+}
+
+//InvalidateInventory records the invalidation of the inventory
+func (m *ScriptedManager) InvalidateInventory() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidations++
+}
+
+//InvalidateFacts records the node sets whose facts are invalidated
+func (m *ScriptedManager) InvalidateFacts(ctx componentizer.TemplateContext, nodeSets ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.factNodeSets = append(m.factNodeSets, nodeSets...)
+	return nil
+}
+
+//ResetFacts records the reset of the facts
+func (m *ScriptedManager) ResetFacts(ctx componentizer.TemplateContext) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.factResets++
+	return nil
+}
+
+//FactResets returns the number of times the facts have been reset so far
+func (m *ScriptedManager) FactResets() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.factResets
+}
+
+//InvalidatedFacts returns the node sets whose facts have been invalidated so far, in order
+func (m *ScriptedManager) InvalidatedFacts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]string, len(m.factNodeSets))
+	copy(res, m.factNodeSets)
+	return res
+}
+
+//Invalidations returns the number of times the inventory has been invalidated so far
+func (m *ScriptedManager) Invalidations() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.invalidations
+}
+
+//Calls returns the playbooks launched so far, in order
+func (m *ScriptedManager) Calls() []PlayCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]PlayCall, len(m.calls))
+	copy(res, m.calls)
+	return res
+}
+
+//Played returns the launches of the given playbook of the component
+func (m *ScriptedManager) Played(component string, playbook string) []PlayCall {
+	res := make([]PlayCall, 0)
+	for _, c := range m.Calls() {
+		if c.Component == component && c.Playbook == playbook {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func (m *ScriptedManager) outcome(component string, playbook string) (ScriptedOutcome, bool) {
+	for _, o := range m.scenario.Outcomes {
+		if (o.Component == "" || o.Component == component) && (o.Playbook == "" || o.Playbook == playbook) {
+			return o, true
+		}
+	}
+	return ScriptedOutcome{}, false
+}
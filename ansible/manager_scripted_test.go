@@ -0,0 +1,60 @@
+package ansible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScenario(t *testing.T) {
+	s, err := ParseScenario([]byte(`
+outcomes:
+  - component: provider
+    playbook: create.yaml
+    code: 2
+  - playbook: deploy.yaml
+    output: |
+      url: http://localhost
+inventory:
+  hosts:
+    host1:
+      name: host1
+      vars:
+        ansible_host: 10.0.0.1
+inventoryError: unreachable
+`))
+	assert.Nil(t, err)
+	if assert.Len(t, s.Outcomes, 2) {
+		assert.Equal(t, ScriptedOutcome{Component: "provider", Playbook: "create.yaml", Code: 2}, s.Outcomes[0])
+		assert.Equal(t, "url: http://localhost\n", s.Outcomes[1].Output)
+	}
+	assert.Equal(t, "10.0.0.1", s.Inventory.Hosts["host1"].Vars["ansible_host"])
+	assert.Equal(t, "unreachable", s.InventoryError)
+
+	_, err = ParseScenario([]byte("outcomes: wrong"))
+	assert.NotNil(t, err)
+}
+
+func TestScriptedOutcome(t *testing.T) {
+	m := CreateScriptedManager(Scenario{
+		Outcomes: []ScriptedOutcome{
+			{Component: "provider", Playbook: "create.yaml", Code: 2},
+			{Playbook: "create.yaml", Code: 3},
+		},
+	})
+
+	o, ok := m.outcome("provider", "create.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, 2, o.Code)
+
+	o, ok = m.outcome("other", "create.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, 3, o.Code)
+
+	_, ok = m.outcome("provider", "setup.yaml")
+	assert.False(t, ok)
+
+	_, err := m.Inventory(nil)
+	assert.Nil(t, err)
+	assert.Len(t, m.Calls(), 0)
+}
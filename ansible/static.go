@@ -0,0 +1,125 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+	"gopkg.in/yaml.v2"
+)
+
+//StaticInventory returns the existing hosts of the node sets using the static
+//provider, grouped by node set
+func StaticInventory(env model.Environment) Inventory {
+	res := Inventory{
+		Hosts:  make(map[string]Host),
+		Groups: make(map[string]Group),
+	}
+	for name, ns := range env.NodeSets {
+		if !ns.IsStatic(env) {
+			continue
+		}
+		group := Group{
+			Children: make(map[string]Group),
+			Hosts:    []string{},
+			Vars:     InventoryVars{},
+		}
+		for _, sh := range ns.Hosts {
+			vars := InventoryVars{NodeSetHostVar: name}
+			// The labels of the host override the ones of its node set
+			labels := make(map[string]interface{}, len(ns.Labels)+len(sh.Labels))
+			for k, v := range ns.Labels {
+				labels[k] = v
+			}
+			for k, v := range sh.Labels {
+				labels[k] = v
+			}
+			if len(labels) > 0 {
+				vars[LabelsHostVar] = labels
+			}
+			res.Hosts[sh.Name] = Host{
+				Name:    sh.Name,
+				Address: sh.Address,
+				User:    sh.User,
+				Port:    sh.Port,
+				NodeSet: name,
+				Vars:    vars,
+			}
+			group.Hosts = append(group.Hosts, sh.Name)
+		}
+		res.Groups[name] = group
+	}
+	return res
+}
+
+// staticInventoryContent returns the static YAML inventory of the existing
+// hosts, holding their own ansible variables, and of the bastion settings, or
+// nil if there isn't any
+func staticInventoryContent(env model.Environment, privateKey string) ([]byte, error) {
+	inv := StaticInventory(env)
+	allVars, groupVars := bastionVars(env, privateKey)
+	if len(inv.Hosts) == 0 && len(allVars) == 0 && len(groupVars) == 0 {
+		return nil, nil
+	}
+	hosts := make(map[string]interface{})
+	for _, ns := range env.NodeSets {
+		if !ns.IsStatic(env) {
+			continue
+		}
+		for _, sh := range ns.Hosts {
+			vars := make(map[string]interface{})
+			for k, v := range sh.Vars {
+				vars[k] = v
+			}
+			for k, v := range inv.Hosts[sh.Name].hostVars() {
+				vars[k] = v
+			}
+			hosts[sh.Name] = vars
+		}
+	}
+
+	// The bastion settings apply to the groups named after the node sets
+	children := yamlGroups(inv.Groups)
+	for name, vars := range groupVars {
+		g, ok := children[name].(map[string]interface{})
+		if !ok {
+			g = make(map[string]interface{})
+		}
+		g["vars"] = map[string]interface{}(vars)
+		children[name] = g
+	}
+	all := map[string]interface{}{
+		"hosts":    hosts,
+		"children": children,
+	}
+	if len(allVars) > 0 {
+		all["vars"] = map[string]interface{}(allVars)
+	}
+	return yaml.Marshal(map[string]interface{}{"all": all})
+}
+
+// buildStaticInventoryArgs writes the static inventory, if needed, and returns the
+// arguments passing it to ansible along with the function removing it
+func (aM manager) buildStaticInventoryArgs(ctx componentizer.TemplateContext) ([]string, func(), error) {
+	noop := func() {}
+	tplC, ok := ctx.(*model.TemplateContext)
+	if !ok {
+		return nil, noop, nil
+	}
+	content, err := staticInventoryContent(tplC.Model, aM.lC.SSHPrivateKey())
+	if err != nil || content == nil {
+		return nil, noop, err
+	}
+	f, err := ioutil.TempFile("", "ekara-static-*.yaml")
+	if err != nil {
+		return nil, noop, err
+	}
+	defer f.Close()
+	if _, err := f.Write(content); err != nil {
+		os.Remove(f.Name())
+		return nil, noop, err
+	}
+	aM.lC.Log().Printf("Static inventory: %s", f.Name())
+	return []string{"-i", f.Name()}, func() { os.Remove(f.Name()) }, nil
+}
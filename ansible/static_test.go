@@ -0,0 +1,77 @@
+package ansible
+
+import (
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const staticDescriptor = `
+name: static
+providers:
+  metal:
+    kind: static
+nodes:
+  db:
+    provider:
+      name: metal
+    labels:
+      role: db
+    hosts:
+      - name: db1
+        address: 10.0.0.1
+        user: centos
+        labels:
+          disk: ssd
+        vars:
+          db_primary: true
+      - address: 10.0.0.2
+        port: 2222
+        labels:
+          role: replica
+`
+
+func TestStaticInventory(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", staticDescriptor)
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+
+	inv := StaticInventory(env)
+	assert.Equal(t, Host{
+		Name:    "db1",
+		Address: "10.0.0.1",
+		User:    "centos",
+		NodeSet: "db",
+		Vars:    InventoryVars{"nodeset": "db", "labels": map[string]interface{}{"role": "db", "disk": "ssd"}},
+	}, inv.Hosts["db1"])
+	assert.Equal(t, 2222, inv.Hosts["10.0.0.2"].Port)
+	if assert.Contains(t, inv.Groups, "db") {
+		assert.ElementsMatch(t, []string{"db1", "10.0.0.2"}, inv.Groups["db"].Hosts)
+	}
+
+	// The hosts inherit the labels of their node set
+	assert.Equal(t, map[string]string{"role": "db", "disk": "ssd"}, inv.Correlate(env).Hosts["db1"].Labels())
+	assert.Equal(t, InventoryVars{"nodeset": "db", "labels": map[string]interface{}{"role": "replica"}}, inv.Hosts["10.0.0.2"].Vars)
+
+	// The ansible variables of the hosts are kept into the static inventory
+	b, err := staticInventoryContent(env, "")
+	assert.Nil(t, err)
+	content := make(map[string]map[string]map[string]interface{})
+	assert.Nil(t, yaml.Unmarshal(b, &content))
+	db1 := content["all"]["hosts"]["db1"].(map[interface{}]interface{})
+	assert.Equal(t, "10.0.0.1", db1["ansible_host"])
+	assert.Equal(t, "centos", db1["ansible_user"])
+	assert.Equal(t, true, db1["db_primary"])
+	assert.Contains(t, content["all"]["children"], "db")
+
+	// Without static node sets there isn't any static inventory
+	b, err = staticInventoryContent(model.Environment{}, "")
+	assert.Nil(t, err)
+	assert.Nil(t, b)
+}
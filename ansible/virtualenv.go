@@ -0,0 +1,142 @@
+package ansible
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/model"
+)
+
+const (
+	//DefaultVirtualenvDir is the directory holding the python virtualenvs, unless
+	//customized into the launch context
+	DefaultVirtualenvDir = "/opt/virtualenvs"
+
+	virtualenvDefault            = "default"
+	virtualenvRequirementsPrefix = "requirements-"
+)
+
+// virtualenv represents a python virtualenv holding the ansible binaries
+type virtualenv struct {
+	// The name of the virtualenv
+	name string
+	// The location of the virtualenv
+	path string
+	// The absolute path of the requirements file describing the virtualenv, if any
+	requirements string
+	// The component requiring the virtualenv, if any
+	owner string
+}
+
+// binPath returns the directory holding the binaries of the virtualenv
+func (v virtualenv) binPath() string {
+	return filepath.Join(v.path, "bin")
+}
+
+// binary returns the path of the given binary within the virtualenv
+func (v virtualenv) binary(name string) string {
+	return filepath.Join(v.binPath(), name)
+}
+
+// check returns an error if the virtualenv doesn't provide the given binary
+func (v virtualenv) check(name string) error {
+	if _, err := os.Stat(v.binary(name)); err == nil {
+		return nil
+	}
+	msg := fmt.Sprintf("the virtualenv %s", v.name)
+	if v.owner != "" {
+		msg = msg + fmt.Sprintf(" required by the component %s", v.owner)
+	}
+	msg = msg + fmt.Sprintf(" is missing, %s cannot be found", v.binary(name))
+	if v.requirements != "" {
+		msg = msg + fmt.Sprintf(", it can be created with \"python3 -m venv %s && %s install -r %s\"", v.path, v.binary("pip"), v.requirements)
+	}
+	return errors.New(msg)
+}
+
+func (aM manager) virtualenvDir() string {
+	if dir := aM.lC.VirtualenvDir(); dir != "" {
+		return dir
+	}
+	return DefaultVirtualenvDir
+}
+
+// componentVirtualenv returns the virtualenv declared by the component, or
+// the default one if the component doesn't declare any
+func (aM manager) componentVirtualenv(uc componentizer.UsableComponent, ctx componentizer.TemplateContext) (virtualenv, error) {
+	var declared model.Virtualenv
+	if tplC, ok := ctx.(*model.TemplateContext); ok {
+		declared = tplC.Model.Platform.Virtualenv(uc.Id())
+	}
+	return resolveVirtualenv(aM.virtualenvDir(), uc, declared)
+}
+
+// inventoryVirtualenv returns the virtualenv declared by the components
+// holding the inventory sources, or the default one if none of them declare
+// any. The components declaring different virtualenvs cannot be mixed.
+func (aM manager) inventoryVirtualenv(inventoryPaths componentizer.MatchingPaths, ctx componentizer.TemplateContext) (virtualenv, error) {
+	res, err := resolveVirtualenv(aM.virtualenvDir(), nil, model.Virtualenv{})
+	if err != nil {
+		return res, err
+	}
+	for _, p := range inventoryPaths.Paths {
+		v, err := aM.componentVirtualenv(p.Owner(), ctx)
+		if err != nil {
+			return res, err
+		}
+		if v.name == virtualenvDefault || v.name == res.name {
+			continue
+		}
+		if res.name != virtualenvDefault {
+			return res, fmt.Errorf("the inventory sources require different virtualenvs: %s for the component %s and %s for the component %s", res.name, res.owner, v.name, v.owner)
+		}
+		res = v
+	}
+	return res, nil
+}
+
+// resolveVirtualenv locates, within the given directory, the virtualenv
+// declared by the component.
+//
+// A virtualenv only declared through its requirements file is named after the
+// content of the file, allowing components with the same requirements to
+// share it.
+func resolveVirtualenv(dir string, uc componentizer.UsableComponent, declared model.Virtualenv) (virtualenv, error) {
+	res := virtualenv{
+		name: declared.Name,
+	}
+	if uc != nil {
+		res.owner = uc.Id()
+	}
+	if declared.Requirements != "" {
+		ok, mp := uc.ContainsFile(declared.Requirements)
+		if !ok {
+			return res, fmt.Errorf("component %s does not contain the virtualenv requirements file: %s", uc.Id(), declared.Requirements)
+		}
+		res.requirements = mp.AbsolutePath()
+		if res.name == "" {
+			b, err := ioutil.ReadFile(res.requirements)
+			if err != nil {
+				return res, err
+			}
+			sum := sha256.Sum256(b)
+			res.name = virtualenvRequirementsPrefix + hex.EncodeToString(sum[:])[:12]
+		}
+	}
+	if res.name == "" {
+		res.name = virtualenvDefault
+		res.owner = ""
+	}
+	if res.name == "." || res.name == ".." || strings.ContainsAny(res.name, `/\`) {
+		return res, fmt.Errorf("invalid virtualenv name: %s", res.name)
+	}
+	res.path = filepath.Join(dir, res.name)
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveVirtualenv(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+
+	repProvider := tester.CreateDir("provider")
+	repProvider.WriteCommit("ekara.yaml", `
+ekara:
+  virtualenv:
+    requirements: requirements.txt
+`)
+	repProvider.WriteCommit("requirements.txt", "openstacksdk==0.46.0\n")
+
+	repOrchestrator := tester.CreateDir("orchestrator")
+	repOrchestrator.WriteCommit("ekara.yaml", `
+ekara:
+  virtualenv:
+    name: swarm
+`)
+
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", `
+name: venv
+ekara:
+  components:
+    provider:
+      repository: provider
+    orchestrator:
+      repository: orchestrator
+orchestrator:
+  component: orchestrator
+providers:
+  p1:
+    component: provider
+nodes:
+  node1:
+    instances: 1
+    provider:
+      name: p1
+`)
+
+	tester.Init(repDesc.AsRepository("master"))
+	env := tester.Env()
+	assert.Equal(t, model.Virtualenv{Requirements: "requirements.txt"}, env.Platform.Virtualenv("provider"))
+	assert.Equal(t, model.Virtualenv{Name: "swarm"}, env.Platform.Virtualenv("orchestrator"))
+	assert.False(t, env.Platform.Virtualenv("descriptor").IsDefined())
+
+	dir, err := ioutil.TempDir("", "virtualenvs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Named virtualenv
+	uo, err := tester.ComponentManager().Use(env.Orchestrator, tester.TemplateContext())
+	assert.Nil(t, err)
+	defer uo.Release()
+	venv, err := resolveVirtualenv(dir, uo, env.Platform.Virtualenv(uo.Id()))
+	assert.Nil(t, err)
+	assert.Equal(t, "swarm", venv.name)
+	assert.Equal(t, filepath.Join(dir, "swarm", "bin"), venv.binPath())
+	err = venv.check("ansible-playbook")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "the virtualenv swarm required by the component orchestrator is missing, "+filepath.Join(dir, "swarm", "bin", "ansible-playbook")+" cannot be found", err.Error())
+	}
+	assert.Nil(t, os.MkdirAll(venv.binPath(), 0755))
+	assert.Nil(t, ioutil.WriteFile(venv.binary("ansible-playbook"), []byte{}, 0755))
+	assert.Nil(t, venv.check("ansible-playbook"))
+
+	// Virtualenv named after its requirements
+	up, err := tester.ComponentManager().Use(env.Providers["p1"], tester.TemplateContext())
+	assert.Nil(t, err)
+	defer up.Release()
+	venv, err = resolveVirtualenv(dir, up, env.Platform.Virtualenv(up.Id()))
+	assert.Nil(t, err)
+	assert.Equal(t, "requirements-84834b72bd3f", venv.name)
+	assert.Equal(t, filepath.Join(up.RootPath(), "requirements.txt"), venv.requirements)
+	err = venv.check("ansible-playbook")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "install -r "+venv.requirements)
+	}
+
+	// Missing requirements file
+	_, err = resolveVirtualenv(dir, up, model.Virtualenv{Requirements: "missing.txt"})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "component provider does not contain the virtualenv requirements file: missing.txt", err.Error())
+	}
+
+	// Default virtualenv
+	venv, err = resolveVirtualenv(dir, up, model.Virtualenv{})
+	assert.Nil(t, err)
+	assert.Equal(t, virtualenvDefault, venv.name)
+	assert.Equal(t, filepath.Join(dir, virtualenvDefault), venv.path)
+
+	// Invalid name
+	_, err = resolveVirtualenv(dir, up, model.Virtualenv{Name: "../other"})
+	assert.NotNil(t, err)
+}
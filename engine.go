@@ -8,6 +8,7 @@ import (
 	"github.com/ekara-platform/engine/action"
 	"github.com/ekara-platform/engine/ansible"
 	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/secret"
 	"github.com/ekara-platform/engine/util"
 )
 
@@ -41,12 +42,18 @@ type engine struct {
 //		lC: the launch context
 //		workDir: the directory where the engine will do its work
 func Create(lC util.LaunchContext, workDir string) Ekara {
+	// Never disclose the sensitive values into the logs and the feedback
+	lC = util.HidingLaunchContext(lC)
+	secret.MarkSensitiveContent(lC.ExternalVars())
+
 	eng := engine{
 		lC:        lC,
 		directory: filepath.Clean(workDir),
 		tplC:      model.CreateTemplateContext(lC.ExternalVars()),
 		actions:   make(map[action.ActionID]action.Action),
 	}
+	eng.tplC.SetLenient(lC.LenientTemplating())
+	eng.tplC.SetEnvAllowlist(lC.TemplateEnvAllowlist()...)
 
 	// Register actions
 	for _, a := range action.All() {
@@ -55,18 +62,30 @@ func Create(lC util.LaunchContext, workDir string) Ekara {
 
 	// Initialize subsystems
 	eng.componentManager = componentizer.CreateComponentManager(eng.lC.Log(), filepath.Join(eng.directory, "components"))
-	eng.ansibleManager = ansible.CreateAnsibleManager(eng.lC, eng.componentManager)
+	eng.ansibleManager = ansible.CreateAnsibleManager(eng.lC, eng.componentManager, filepath.Join(eng.directory, "facts"))
 
 	return &eng
 }
 
 func (eng *engine) Init(repo componentizer.Repository) error {
+	if kf := eng.lC.SecretKeyFile(); kf != "" {
+		key, err := secret.LoadKey(kf)
+		if err != nil {
+			return fmt.Errorf("unable to load the secret key: %s", err.Error())
+		}
+		eng.tplC.SetSecretKey(key)
+	}
 	m, err := eng.componentManager.Init(model.CreateComponent(model.MainComponentId, repo), eng.tplC)
 	if err != nil {
 		return err
 	}
+	// The missing keys are only known once all the components are fetched
+	if err := eng.tplC.CheckDescriptors(); err != nil {
+		return err
+	}
 	eng.environment = m.(model.Environment)
 	eng.tplC.Model = m.(model.Environment)
+	secret.MarkSensitiveContent(eng.tplC.Vars)
 	return nil
 }
 
@@ -75,7 +94,16 @@ func (eng *engine) Environment() model.Environment {
 }
 
 func (eng *engine) Execute(id action.ActionID) (action.Result, error) {
-	rC := action.CreateRuntimeContext(eng.lC, eng.componentManager, eng.ansibleManager, eng.environment, eng.tplC)
+	env, e := eng.policyEnvironment()
+	if e != nil {
+		return nil, e
+	}
+	// The nodes may have changed since the previous execution
+	eng.ansibleManager.InvalidateInventory()
+	if e := eng.ansibleManager.ResetFacts(eng.tplC); e != nil {
+		return nil, fmt.Errorf("unable to reset the facts cache: %s", e.Error())
+	}
+	rC := action.CreateRuntimeContext(eng.lC, eng.componentManager, eng.ansibleManager, env, eng.tplC)
 	r := &action.ExecutionReport{}
 
 	// Execute the action chain
@@ -94,6 +122,25 @@ func (eng *engine) Execute(id action.ActionID) (action.Result, error) {
 	return res, nil
 }
 
+// policyEnvironment returns the environment evaluating the policy rules
+// registered into the launch context, along with the ones of its policy
+// rules file, if any
+func (eng *engine) policyEnvironment() (model.Environment, error) {
+	rules := eng.lC.PolicyRules()
+	if f := eng.lC.PolicyRulesFile(); f != "" {
+		fRules, err := model.ParsePolicyRules(f)
+		if err != nil {
+			return eng.environment, fmt.Errorf("unable to load the policy rules: %s", err.Error())
+		}
+		eng.lC.Log().Printf("Policy rules loaded from %s: %d", f, len(fRules))
+		rules = append(append([]model.PolicyRule{}, rules...), fRules...)
+	}
+	if len(rules) == 0 {
+		return eng.environment, nil
+	}
+	return eng.environment.WithPolicyRules(rules...), nil
+}
+
 func (eng *engine) execute(id action.ActionID, rC *action.RuntimeContext, report *action.ExecutionReport) (action.Result, error) {
 	a, ok := eng.actions[id]
 	if !ok {
@@ -1,9 +1,11 @@
 package engine
 
 import (
-	"github.com/ekara-platform/engine/model"
 	"testing"
 
+	"github.com/ekara-platform/engine/action"
+	"github.com/ekara-platform/engine/ansible"
+	"github.com/ekara-platform/engine/model"
 	"github.com/ekara-platform/engine/util"
 	"github.com/stretchr/testify/assert"
 )
@@ -90,3 +92,21 @@ func TestEngineLocalWithTagRef(t *testing.T) {
 	assert.NotNil(t, env)
 	assert.Equal(t, "newTag1", env.QName.Qualifier)
 }
+
+func TestEngineExecuteResetsCaches(t *testing.T) {
+	aM := ansible.CreateScriptedManager(ansible.Scenario{})
+	eng := &engine{
+		lC:             util.CreateMockLaunchContext(false),
+		tplC:           model.CreateTemplateContext(model.CreateEmptyParameters()),
+		actions:        make(map[action.ActionID]action.Action),
+		ansibleManager: aM,
+	}
+
+	// Each execution starts with a new inventory and without the previous facts
+	_, err := eng.Execute(action.CheckActionID)
+	assert.NotNil(t, err)
+	_, err = eng.Execute(action.CheckActionID)
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, aM.Invalidations())
+	assert.Equal(t, 2, aM.FactResets())
+}
@@ -3,6 +3,7 @@ module github.com/ekara-platform/engine
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.3.0
 	github.com/GroupePSA/componentizer v0.0.0-20200928085853-dd944290e104
 	github.com/google/uuid v1.1.2 // indirect
 	github.com/json-iterator/go v1.1.10
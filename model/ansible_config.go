@@ -0,0 +1,36 @@
+package model
+
+import (
+	"fmt"
+)
+
+//AnsibleConfig holds the settings of the ansible.cfg file used to launch the
+//playbooks, by section
+type AnsibleConfig map[string]map[string]string
+
+func createAnsibleConfig(yConfig map[string]map[string]interface{}) AnsibleConfig {
+	res := AnsibleConfig{}
+	for section, settings := range yConfig {
+		res[section] = make(map[string]string, len(settings))
+		for k, v := range settings {
+			res[section][k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return res
+}
+
+//Override returns the settings overridden, one by one, by the given ones
+func (r AnsibleConfig) Override(with AnsibleConfig) AnsibleConfig {
+	res := AnsibleConfig{}
+	for _, c := range []AnsibleConfig{r, with} {
+		for section, settings := range c {
+			if _, ok := res[section]; !ok {
+				res[section] = make(map[string]string, len(settings))
+			}
+			for k, v := range settings {
+				res[section][k] = v
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnsibleConfig(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/ansible_config.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+
+	// The settings are kept as strings
+	assert.Equal(t, AnsibleConfig{
+		"defaults":       {"forks": "50", "gathering": "smart"},
+		"ssh_connection": {"pipelining": "true"},
+	}, env.Ansible)
+}
+
+func TestAnsibleConfigOverride(t *testing.T) {
+	base := AnsibleConfig{
+		"defaults":       {"forks": "20", "timeout": "10"},
+		"ssh_connection": {"pipelining": "false"},
+	}
+	res := base.Override(AnsibleConfig{
+		"defaults":  {"forks": "50"},
+		"inventory": {"enable_plugins": "yaml"},
+	})
+	assert.Equal(t, AnsibleConfig{
+		"defaults":       {"forks": "50", "timeout": "10"},
+		"ssh_connection": {"pipelining": "false"},
+		"inventory":      {"enable_plugins": "yaml"},
+	}, res)
+
+	// The overridden settings are left untouched
+	assert.Equal(t, "20", base["defaults"]["forks"])
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+//Bastion is the jump host through which the hosts of the node sets are connected
+type Bastion struct {
+	// Host is the address of the bastion
+	Host string `yaml:",omitempty" json:",omitempty"`
+	// User is the user connecting the bastion
+	User string `yaml:",omitempty" json:",omitempty"`
+	// Key is the path of the private key connecting the bastion, the one of the engine if empty
+	Key string `yaml:",omitempty" json:",omitempty"`
+	// Port is the SSH port of the bastion
+	Port int `yaml:",omitempty" json:",omitempty"`
+	// HostKeyChecking is the StrictHostKeyChecking mode connecting the bastion, the one of the SSH configuration if empty
+	HostKeyChecking string `yaml:"host_key_checking,omitempty" json:",omitempty"`
+}
+
+//BastionHostKeyCheckings lists the accepted host key checking modes of a bastion
+var BastionHostKeyCheckings = []string{"yes", "no", "accept-new"}
+
+func createBastion(yB yamlBastion) Bastion {
+	return Bastion{
+		Host:            yB.Host,
+		User:            yB.User,
+		Key:             yB.Key,
+		Port:            yB.Port,
+		HostKeyChecking: yB.HostKeyChecking,
+	}
+}
+
+//IsDefined returns true if the hosts are connected through a bastion
+func (r Bastion) IsDefined() bool {
+	return r.Host != ""
+}
+
+func (r Bastion) override(with Bastion) Bastion {
+	if with.Host != "" {
+		r.Host = with.Host
+	}
+	if with.User != "" {
+		r.User = with.User
+	}
+	if with.Key != "" {
+		r.Key = with.Key
+	}
+	if with.Port != 0 {
+		r.Port = with.Port
+	}
+	if with.HostKeyChecking != "" {
+		r.HostKeyChecking = with.HostKeyChecking
+	}
+	return r
+}
+
+func (r Bastion) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	if r.Port < 0 {
+		vErrs.addError(errors.New("the port cannot be negative"), loc.appendPath("port"))
+	}
+	if r.Key != "" {
+		if _, err := os.Stat(r.Key); err != nil {
+			vErrs.addError(fmt.Errorf("the bastion key %s cannot be read", r.Key), loc.appendPath("key"))
+		}
+	}
+	if r.HostKeyChecking != "" && !isBastionHostKeyChecking(r.HostKeyChecking) {
+		vErrs.addError(fmt.Errorf("the host key checking must be one of %v", BastionHostKeyCheckings), loc.appendPath("host_key_checking"))
+	}
+	return vErrs
+}
+
+func isBastionHostKeyChecking(mode string) bool {
+	for _, m := range BastionHostKeyCheckings {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBastion(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/bastion.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+
+	global := Bastion{Host: "bastion.example.com", User: "jump", Key: "./testdata/keys/id_bastion"}
+	assert.Equal(t, global, env.Bastion)
+	assert.Equal(t, Bastion{Host: "10.0.0.254", Port: 2222, HostKeyChecking: "accept-new"}, env.Providers["private"].Bastion())
+
+	// The node set bastion overrides the provider one, overriding the global one
+	assert.Equal(t, global, env.NodeSets["public"].EffectiveBastion(env))
+	assert.Equal(t, Bastion{Host: "10.0.0.254", User: "jump", Key: "./testdata/keys/id_bastion", Port: 2222, HostKeyChecking: "accept-new"}, env.NodeSets["private"].EffectiveBastion(env))
+	assert.Equal(t, Bastion{Host: "10.0.0.254", User: "admin", Key: "./testdata/keys/missing", Port: 2222, HostKeyChecking: "accept-new"}, env.NodeSets["isolated"].EffectiveBastion(env))
+	assert.False(t, Bastion{}.IsDefined())
+	assert.True(t, global.IsDefined())
+
+	// The provider bastion is kept by the resolved provider
+	p, e := env.NodeSets["private"].Provider.Resolve(env)
+	assert.Nil(t, e)
+	assert.Equal(t, "10.0.0.254", p.Bastion().Host)
+
+	vErrs := env.Validate()
+	assert.False(t, vErrs.contains(Error, "the bastion key ./testdata/keys/id_bastion cannot be read", "bastion.key"))
+	assert.False(t, vErrs.contains(Error, "the bastion requires a host", "nodes.isolated.bastion.host"))
+	assert.True(t, vErrs.contains(Error, "the port cannot be negative", "providers.wrong.bastion.port"))
+	assert.True(t, vErrs.contains(Error, "the host key checking must be one of [yes no accept-new]", "providers.wrong.bastion.host_key_checking"))
+	assert.False(t, vErrs.contains(Error, "the host key checking must be one of [yes no accept-new]", "providers.private.bastion.host_key_checking"))
+	assert.True(t, vErrs.contains(Error, "the bastion key ./testdata/keys/missing cannot be read", "nodes.isolated.bastion.key"))
+}
+
+func TestBastionWithoutHost(t *testing.T) {
+	env := Environment{
+		Providers: Providers{"p1": {Name: "p1", bastion: Bastion{User: "jump"}}},
+		NodeSets:  NodeSets{"node1": {Name: "node1", Instances: 1, Provider: ProviderRef{ref: "p1"}}},
+	}
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "the bastion requires a host", "nodes.node1.bastion.host"))
+
+	env.Bastion = Bastion{Host: "bastion.example.com"}
+	vErrs = env.Validate()
+	assert.False(t, vErrs.contains(Error, "the bastion requires a host", "nodes.node1.bastion.host"))
+}
@@ -20,6 +20,16 @@ type (
 		Templates []string
 		// Playbooks define the playbooks paths for the component
 		Playbooks map[string]string
+		// Virtualenv defines the python virtualenv used to run ansible for the component
+		Virtualenv Virtualenv
+	}
+
+	// Virtualenv represents the python virtualenv used to run ansible
+	Virtualenv struct {
+		// The name of the virtualenv
+		Name string
+		// The requirements file, within the component, describing the virtualenv
+		Requirements string
 	}
 
 	componentRef struct {
@@ -40,9 +50,35 @@ func (c *component) merge(with component) {
 	c.Id = with.Id
 	c.Repository.Merge(with.Repository)
 	c.Templates = union(c.Templates, with.Templates)
+	if c.Playbooks == nil {
+		c.Playbooks = make(map[string]string)
+	}
 	for k, v := range with.Playbooks {
 		c.Playbooks[k] = v
 	}
+	if with.Virtualenv.IsDefined() {
+		c.Virtualenv = with.Virtualenv
+	}
+}
+
+// IsDefined returns true if a specific virtualenv has been declared
+func (v Virtualenv) IsDefined() bool {
+	return v.Name != "" || v.Requirements != ""
+}
+
+// playbook returns the custom playbook overriding the given default one, or
+// the default one if not overridden.
+//
+// The overridden playbook can be referenced by its file name, "create.yaml",
+// or by its name without extension, "create".
+func (c component) playbook(defaultPlaybook string) string {
+	if p := c.Playbooks[defaultPlaybook]; p != "" {
+		return p
+	}
+	if p := c.Playbooks[strings.TrimSuffix(defaultPlaybook, filepath.Ext(defaultPlaybook))]; p != "" {
+		return p
+	}
+	return defaultPlaybook
 }
 
 func (c component) String() string {
@@ -75,7 +111,7 @@ func (c component) ParseComponents(path string, tplC componentizer.TemplateConte
 	}
 
 	yamlRefs := yamlRefs{}
-	err := parseYaml(descPath, tplC.(*TemplateContext), &yamlRefs)
+	err := parseNamedYaml(c.templateName(), descPath, tplC.(*TemplateContext), &yamlRefs)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -112,7 +148,7 @@ func (c component) ParseModel(path string, tplC componentizer.TemplateContext) (
 	}
 
 	yamlEnv := yamlEnvironment{}
-	err := parseYaml(filepath.Join(path, DefaultDescriptorName), tplC.(*TemplateContext), &yamlEnv)
+	err := parseNamedYaml(c.templateName(), descPath, tplC.(*TemplateContext), &yamlEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +159,11 @@ func (c component) GetTemplates() (bool, []string) {
 	return len(c.Templates) > 0, c.Templates
 }
 
+// templateName returns the name under which the component descriptor is templated
+func (c component) templateName() string {
+	return "Component:" + c.Id
+}
+
 func (c component) Descriptor() string {
 	return DefaultDescriptorName
 }
@@ -25,6 +25,14 @@ type (
 		Tasks Tasks
 		// The hooks linked to the environment lifecycle events
 		Hooks EnvironmentHooks
+		// The global volumes and their content
+		Volumes GlobalVolumes
+		// The bastion through which all the hosts are connected
+		Bastion Bastion
+		// The settings of the ansible.cfg file used to launch the playbooks
+		Ansible AnsibleConfig
+		// The organisation rules the environment must comply with
+		policies []PolicyRule
 		// The location of the environment root
 		loc DescriptorLocation
 	}
@@ -56,6 +64,9 @@ func CreateEnvironment(from component, yamlEnv yamlEnvironment) (Environment, er
 	env.NodeSets = createNodeSets(yamlEnv)
 	env.Stacks = createStacks(from, yamlEnv)
 	env.Hooks = createEnvHooks(yamlEnv)
+	env.Volumes = createGlobalVolumes(yamlEnv)
+	env.Bastion = createBastion(yamlEnv.Bastion)
+	env.Ansible = createAnsibleConfig(yamlEnv.Ansible)
 
 	return env, nil
 }
@@ -72,6 +83,9 @@ func (r Environment) Merge(with componentizer.Model) (componentizer.Model, error
 	r.Stacks.merge(env.Stacks)
 	r.Tasks.merge(env.Tasks)
 	r.Hooks.merge(env.Hooks)
+	r.Volumes.merge(env.Volumes)
+	r.Bastion = r.Bastion.override(env.Bastion)
+	r.Ansible = r.Ansible.Override(env.Ansible)
 
 	return r, nil
 }
@@ -117,6 +131,16 @@ func (r Environment) IsReferenced(c1 componentizer.Component) bool {
 		}
 	}
 
+	// Check global volumes content
+	for _, volume := range r.Volumes {
+		for _, content := range volume.Content {
+			c2, err := content.Component(r)
+			if err == nil && c1.ComponentId() == c2.ComponentId() {
+				return true
+			}
+		}
+	}
+
 	return false
 }
 
@@ -137,5 +161,9 @@ func (r Environment) Validate() ValidationErrors {
 	vErrs.merge(validate(r, r.loc.appendPath("stacks"), r.Stacks))
 	vErrs.merge(validate(r, r.loc.appendPath("tasks"), r.Tasks))
 	vErrs.merge(validate(r, r.loc.appendPath("hooks"), r.Hooks))
+	vErrs.merge(validate(r, r.loc.appendPath("volumes"), r.Volumes))
+	vErrs.merge(validate(r, r.loc.appendPath("bastion"), r.Bastion))
+	vErrs.merge(validateCircularRefs(r, r.loc.appendPath("tasks")))
+	vErrs.merge(validatePolicies(r))
 	return vErrs
 }
@@ -1,5 +1,7 @@
 package model
 
+import "sort"
+
 type graph struct {
 	nodes   []string
 	outputs map[string]map[string]int
@@ -78,3 +80,54 @@ func (g *graph) sort() ([]string, bool) {
 
 	return L, true
 }
+
+// cycle returns the nodes of a cycle following the edges of the graph, the
+// first node being repeated at the end, or nil if the graph has no cycle.
+func (g *graph) cycle() []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	path := make([]string, 0, len(g.nodes))
+
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		state[n] = visiting
+		path = append(path, n)
+		targets := make([]string, 0, len(g.outputs[n]))
+		for m := range g.outputs[n] {
+			targets = append(targets, m)
+		}
+		sort.Strings(targets)
+		for _, m := range targets {
+			switch state[m] {
+			case visiting:
+				for i, p := range path {
+					if p == m {
+						res := append([]string{}, path[i:]...)
+						return append(res, m)
+					}
+				}
+			case 0:
+				if res := visit(m); res != nil {
+					return res
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+		return nil
+	}
+
+	names := append([]string{}, g.nodes...)
+	sort.Strings(names)
+	for _, n := range names {
+		if state[n] == 0 {
+			if res := visit(n); res != nil {
+				return res
+			}
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package model
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 )
 
 type (
@@ -48,3 +50,57 @@ func (r *Hook) merge(with Hook) {
 func (r Hook) HasTasks() bool {
 	return len(r.Before) > 0 || len(r.After) > 0
 }
+
+func (r Hook) refs() []TaskRef {
+	res := make([]TaskRef, 0, len(r.Before)+len(r.After))
+	res = append(res, r.Before...)
+	return append(res, r.After...)
+}
+
+// validateCircularRefs walks the whole hook graph, starting from the
+// environment, node set, stack and task hooks, and reports each circular
+// task reference once, located on the task where the cycle starts.
+func validateCircularRefs(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+
+	roots := make([]TaskRef, 0)
+	for _, h := range []Hook{e.Hooks.Init, e.Hooks.Create, e.Hooks.Install, e.Hooks.Deploy, e.Hooks.Destroy} {
+		roots = append(roots, h.refs()...)
+	}
+	nsNames := make([]string, 0, len(e.NodeSets))
+	for name := range e.NodeSets {
+		nsNames = append(nsNames, name)
+	}
+	sort.Strings(nsNames)
+	for _, name := range nsNames {
+		roots = append(roots, e.NodeSets[name].Hooks.Create.refs()...)
+		roots = append(roots, e.NodeSets[name].Hooks.Destroy.refs()...)
+	}
+	stNames := make([]string, 0, len(e.Stacks))
+	for name := range e.Stacks {
+		stNames = append(stNames, name)
+	}
+	sort.Strings(stNames)
+	for _, name := range stNames {
+		roots = append(roots, e.Stacks[name].Hooks.Deploy.refs()...)
+	}
+	tNames := make([]string, 0, len(e.Tasks))
+	for name := range e.Tasks {
+		tNames = append(tNames, name)
+	}
+	sort.Strings(tNames)
+	for _, name := range tNames {
+		roots = append(roots, TaskRef{ref: name})
+	}
+
+	reported := make(map[string]bool)
+	for _, cycle := range checkCircularRefs(e, roots, circularRefTracking{}, make(map[string]bool)) {
+		cycle = cycle.canonical()
+		if reported[cycle.String()] {
+			continue
+		}
+		reported[cycle.String()] = true
+		vErrs.addError(fmt.Errorf("circular task reference: %s", cycle), loc.appendPath(cycle[0]).appendPath("hooks").appendPath("execute"))
+	}
+	return vErrs
+}
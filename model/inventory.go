@@ -0,0 +1,30 @@
+package model
+
+type (
+	//Inventory gives access to the hosts created for the node sets, as reported by the
+	//providers.
+	//
+	//It is available to the templates, for example to configure a stack with the
+	//addresses of the hosts of another node set:
+	//  {{ range .Inventory.ByNodeSet "workers" }}{{ .Address }} {{ end }}
+	Inventory interface {
+		//ByNodeSet returns the hosts of the given node set
+		ByNodeSet(name string) ([]InventoryHost, error)
+		//BySelector returns the hosts whose labels match the given label selector
+		BySelector(selector string) ([]InventoryHost, error)
+	}
+
+	//InventoryHost is a host of the inventory
+	InventoryHost struct {
+		// Name is the name of the host into the inventory
+		Name string
+		// Address is the address used to connect the host
+		Address string
+		// NodeSet is the name of the node set holding the host, if known
+		NodeSet string
+		// Labels are the labels of the host, inherited from its node set
+		Labels Labels
+		// Vars are the ekara variables reported for the host
+		Vars map[string]interface{}
+	}
+)
@@ -1,5 +1,10 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
 //Labels represents used defined labels which will be placed on the created environment
 //machines and also on the nodes for Docker
 type Labels map[string]string
@@ -14,3 +19,138 @@ func (r Labels) override(parent Labels) Labels {
 	}
 	return dst
 }
+
+type (
+	//LabelSelector selects labels through a list of requirements, all of them must be met.
+	//
+	//The syntax follows the one of the Kubernetes label selectors, the requirements
+	//being separated by commas:
+	//  role=db, tier!=front, env in (prod,staging), zone notin (a,b), gpu, !legacy
+	LabelSelector struct {
+		requirements []labelRequirement
+	}
+
+	// labelRequirement is a requirement on the value of a label
+	labelRequirement struct {
+		key      string
+		operator string
+		values   []string
+	}
+)
+
+const (
+	labelEquals    = "="
+	labelNotEquals = "!="
+	labelIn        = "in"
+	labelNotIn     = "notin"
+	labelExists    = "exists"
+	labelNotExists = "!"
+)
+
+//ParseLabelSelector parses a label selector, an empty selector matches any labels
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	res := LabelSelector{requirements: make([]labelRequirement, 0)}
+	for _, r := range splitRequirements(s) {
+		if r == "" {
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			return res, fmt.Errorf("invalid label selector \"%s\": empty requirement", s)
+		}
+		req, err := parseLabelRequirement(r)
+		if err != nil {
+			return res, fmt.Errorf("invalid label selector \"%s\": %s", s, err.Error())
+		}
+		res.requirements = append(res.requirements, req)
+	}
+	return res, nil
+}
+
+//Matches returns true if the labels meet all the requirements of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	v, ok := labels[r.key]
+	switch r.operator {
+	case labelEquals:
+		return ok && v == r.values[0]
+	case labelNotEquals:
+		return !ok || v != r.values[0]
+	case labelIn:
+		return ok && contains(r.values, v)
+	case labelNotIn:
+		return !ok || !contains(r.values, v)
+	case labelNotExists:
+		return !ok
+	}
+	return ok
+}
+
+// splitRequirements splits the selector on the commas which are not within a set of values
+func splitRequirements(s string) []string {
+	res := make([]string, 0)
+	depth := 0
+	start := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				res = append(res, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(res, strings.TrimSpace(s[start:]))
+}
+
+func parseLabelRequirement(r string) (labelRequirement, error) {
+	res := labelRequirement{}
+	if strings.HasPrefix(r, "!") && !strings.ContainsAny(r, "=(") {
+		res.key = strings.TrimSpace(r[1:])
+		res.operator = labelNotExists
+	} else if i := strings.Index(r, "!="); i >= 0 {
+		res.key = strings.TrimSpace(r[:i])
+		res.operator = labelNotEquals
+		res.values = []string{strings.TrimSpace(r[i+2:])}
+	} else if i := strings.Index(r, "="); i >= 0 {
+		res.key = strings.TrimSpace(r[:i])
+		res.operator = labelEquals
+		res.values = []string{strings.TrimSpace(strings.TrimPrefix(r[i+1:], "="))}
+	} else if f := strings.Fields(r); len(f) > 1 {
+		res.key = f[0]
+		res.operator = f[1]
+		if res.operator != labelIn && res.operator != labelNotIn {
+			return res, fmt.Errorf("unknown operator \"%s\"", res.operator)
+		}
+		set := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(r[len(f[0]):]), res.operator))
+		if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+			return res, fmt.Errorf("the values of the label %s must be enclosed in parentheses", res.key)
+		}
+		for _, v := range strings.Split(set[1:len(set)-1], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				res.values = append(res.values, v)
+			}
+		}
+		if len(res.values) == 0 {
+			return res, fmt.Errorf("no values for the label %s", res.key)
+		}
+	} else {
+		res.key = r
+		res.operator = labelExists
+	}
+	if res.key == "" || strings.ContainsAny(res.key, " \t()!=") {
+		return res, fmt.Errorf("invalid label key \"%s\"", res.key)
+	}
+	return res, nil
+}
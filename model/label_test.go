@@ -38,3 +38,33 @@ func TestLabelsInherits(t *testing.T) {
 	assert.Equal(t, "val4", val)
 
 }
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"role": "db", "env": "prod"}
+
+	matches := func(selector string) bool {
+		s, err := ParseLabelSelector(selector)
+		assert.Nil(t, err)
+		return s.Matches(labels)
+	}
+	assert.True(t, matches(""))
+	assert.True(t, matches("role=db"))
+	assert.True(t, matches("role==db"))
+	assert.False(t, matches("role=web"))
+	assert.True(t, matches("role!=web"))
+	assert.True(t, matches("zone!=a"))
+	assert.True(t, matches("env in (prod, staging)"))
+	assert.False(t, matches("env notin (prod,staging)"))
+	assert.True(t, matches("zone notin (a)"))
+	assert.True(t, matches("role"))
+	assert.False(t, matches("!role"))
+	assert.True(t, matches("role=db, env in (prod), !legacy"))
+	assert.False(t, matches("role=db,env=dev"))
+}
+
+func TestLabelSelectorInvalid(t *testing.T) {
+	for _, s := range []string{"role,", "env in prod", "env in ()", "env like (a)", "=db", "env in (a"} {
+		_, err := ParseLabelSelector(s)
+		assert.NotNil(t, err, s)
+	}
+}
@@ -21,10 +21,18 @@ type (
 		Instances int
 		// The ref to the provider where to create the machines
 		Provider ProviderRef
+		// The node set specific settings of the orchestrator
+		Orchestrator OrchestratorRef
 		// The hooks linked to the node set lifecycle events
 		Hooks NodeHooks
 		// The labels associated with the nodeset
 		Labels Labels
+		// The volumes to create on the machines of the node set
+		Volumes Volumes
+		// The existing hosts of the node set, for the static provider
+		Hosts StaticHosts
+		// The bastion through which the hosts of the node set are connected
+		Bastion Bastion
 	}
 
 	NodeHooks struct {
@@ -51,7 +59,13 @@ func (r *NodeSet) merge(with NodeSet) {
 	r.Instances = with.Instances
 	r.Labels = r.Labels.override(with.Labels)
 	r.Provider.merge(with.Provider)
+	r.Orchestrator.merge(with.Orchestrator)
 	r.Hooks.merge(with.Hooks)
+	r.Volumes = r.Volumes.merge(with.Volumes)
+	if len(with.Hosts) > 0 {
+		r.Hosts = with.Hosts
+	}
+	r.Bastion = r.Bastion.override(with.Bastion)
 }
 
 func (r *NodeHooks) merge(with NodeHooks) {
@@ -88,10 +102,16 @@ func createNodeSets(yamlEnv yamlEnvironment) NodeSets {
 }
 
 func buildNode(name string, yN yamlNode) NodeSet {
+	instances := yN.Instances
+	if instances == 0 {
+		// The number of existing hosts, if any, is the default number of instances
+		instances = len(yN.Hosts)
+	}
 	return NodeSet{
-		Name:      name,
-		Instances: yN.Instances,
-		Provider:  createProviderRef(yN.Provider),
+		Name:         name,
+		Instances:    instances,
+		Provider:     createProviderRef(yN.Provider),
+		Orchestrator: createOrchestratorRef(yN.Orchestrator),
 		Hooks: struct {
 			Create  Hook
 			Destroy Hook
@@ -99,7 +119,10 @@ func buildNode(name string, yN yamlNode) NodeSet {
 			Create:  createHook("create", yN.Hooks.Create),
 			Destroy: createHook("destroy", yN.Hooks.Destroy),
 		},
-		Labels: yN.Labels,
+		Labels:  yN.Labels,
+		Volumes: createVolumes(yN.Volumes),
+		Hosts:   createStaticHosts(yN.Hosts),
+		Bastion: createBastion(yN.Bastion),
 	}
 }
 
@@ -121,9 +144,44 @@ func (r NodeSet) validate(e Environment, loc DescriptorLocation) ValidationError
 	}
 	vErrs.merge(validate(e, loc.appendPath("provider"), r.Provider))
 	vErrs.merge(validate(e, loc.appendPath("hooks"), r.Hooks))
+	vErrs.merge(validate(e, loc.appendPath("volumes"), r.Volumes))
+	vErrs.merge(validate(e, loc.appendPath("bastion"), r.Bastion))
+	if b := r.EffectiveBastion(e); b != (Bastion{}) && !b.IsDefined() {
+		// The host can be inherited from the provider or the global bastion
+		vErrs.addError(errors.New("the bastion requires a host"), loc.appendPath("bastion.host"))
+	}
+	if p, ok := e.Providers[r.Provider.ref]; ok {
+		if p.IsStatic() {
+			if len(r.Hosts) == 0 {
+				vErrs.addError(errors.New("the node set of a static provider requires hosts"), loc.appendPath("hosts"))
+			} else if r.Instances != len(r.Hosts) {
+				vErrs.addError(errors.New("the instances must match the number of hosts of a static provider"), loc.appendPath("instances"))
+			}
+			vErrs.merge(validate(e, loc.appendPath("hosts"), r.Hosts))
+		} else if len(r.Hosts) > 0 {
+			vErrs.addError(errors.New("only the node sets of a static provider can declare hosts"), loc.appendPath("hosts"))
+		}
+	}
 	return vErrs
 }
 
+//IsStatic returns true if the node set uses the static provider
+func (r NodeSet) IsStatic(e Environment) bool {
+	p, ok := e.Providers[r.Provider.ref]
+	return ok && p.IsStatic()
+}
+
+//EffectiveBastion returns the bastion through which the hosts of the node set
+//are connected, the one of the node set overriding the one of its provider and
+//the global one
+func (r NodeSet) EffectiveBastion(e Environment) Bastion {
+	res := e.Bastion
+	if p, ok := e.Providers[r.Provider.ref]; ok {
+		res = res.override(p.bastion)
+	}
+	return res.override(r.Bastion)
+}
+
 func (r NodeHooks) validate(e Environment, loc DescriptorLocation) ValidationErrors {
 	return validate(e, loc, r.Create, r.Destroy)
 }
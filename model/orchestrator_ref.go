@@ -0,0 +1,41 @@
+package model
+
+type (
+	// OrchestratorRef represents the node set specific settings of the orchestrator
+	OrchestratorRef struct {
+		params  Parameters
+		envVars EnvVars
+	}
+)
+
+func createOrchestratorRef(yamlRef yamlOrchestratorRef) OrchestratorRef {
+	return OrchestratorRef{
+		params:  CreateParameters(yamlRef.Params),
+		envVars: CreateEnvVars(yamlRef.Env),
+	}
+}
+
+func (r *OrchestratorRef) merge(with OrchestratorRef) {
+	r.params = r.params.Override(with.params)
+	r.envVars = r.envVars.Override(with.envVars)
+}
+
+// Resolve returns the global orchestrator overridden by the node set specific settings
+func (r OrchestratorRef) Resolve(model interface{}) (Orchestrator, error) {
+	orchestrator := model.(Environment).Orchestrator
+	return Orchestrator{
+		cRef:    orchestrator.cRef,
+		params:  orchestrator.params.Override(r.params),
+		envVars: orchestrator.envVars.Override(r.envVars),
+	}, nil
+}
+
+// Parameters returns the node set specific orchestrator parameters
+func (r OrchestratorRef) Parameters() Parameters {
+	return r.params
+}
+
+// EnvVars returns the node set specific orchestrator environment variables
+func (r OrchestratorRef) EnvVars() EnvVars {
+	return r.envVars
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrchestratorRefResolve(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/orchestrator_nodes.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+
+	managers, err := env.NodeSets["managers"].Orchestrator.Resolve(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "swarm", managers.ComponentId())
+	assert.Equal(t, "manager", managers.Parameters()["role"])
+	assert.Equal(t, "docker", managers.Parameters()["engine"])
+	assert.Equal(t, "json-file", managers.Parameters()["log_driver"])
+	assert.Equal(t, "managers", managers.EnvVars()["ORCH_ENV"])
+
+	workers, err := env.NodeSets["workers"].Orchestrator.Resolve(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "worker", workers.Parameters()["role"])
+	assert.Equal(t, "json-file", workers.Parameters()["log_driver"])
+	assert.Equal(t, "global", workers.EnvVars()["ORCH_ENV"])
+
+	// The global orchestrator is left untouched
+	assert.Equal(t, "worker", env.Orchestrator.Parameters()["role"])
+	_, ok := env.Orchestrator.Parameters()["log_driver"]
+	assert.False(t, ok)
+}
@@ -41,6 +41,10 @@ func createPlatform(from component, yamlEkara yamlEkara) (Platform, error) {
 	if ok {
 		c.Templates = yamlEkara.Templates
 		c.Playbooks = yamlEkara.Playbooks
+		c.Virtualenv = Virtualenv{
+			Name:         yamlEkara.Virtualenv.Name,
+			Requirements: yamlEkara.Virtualenv.Requirements,
+		}
 	} else {
 		return Platform{}, fmt.Errorf("missing component %s", from.ComponentId())
 	}
@@ -49,6 +53,21 @@ func createPlatform(from component, yamlEkara yamlEkara) (Platform, error) {
 	return p, nil
 }
 
+// Playbook returns the playbook to launch into the given component in place
+// of the default one, as customized into the "playbooks" section of the
+// component descriptor
+func (p Platform) Playbook(componentId string, defaultPlaybook string) string {
+	if c, ok := p.Components[componentId]; ok {
+		return c.playbook(defaultPlaybook)
+	}
+	return defaultPlaybook
+}
+
+// Virtualenv returns the python virtualenv declared by the given component
+func (p Platform) Virtualenv(componentId string) Virtualenv {
+	return p.Components[componentId].Virtualenv
+}
+
 func (p *Platform) registerComponent(c component) error {
 	if _, ok := p.Components[c.Id]; ok {
 		existing := p.Components[c.Id]
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+)
+
+type (
+	//PlayOptions represents the options used to launch a playbook
+	PlayOptions struct {
+		// Limit restricts the hosts targeted by the playbook
+		Limit string `json:",omitempty"`
+		// Tags restricts the playbook to the tasks and roles tagged with them
+		Tags []string `json:",omitempty"`
+		// SkipTags excludes the tasks and roles tagged with them
+		SkipTags []string `json:",omitempty"`
+		// Forks is the number of hosts processed in parallel
+		Forks int `json:",omitempty"`
+		// StartAtTask is the name of the task where to start the playbook
+		StartAtTask string `json:",omitempty"`
+	}
+)
+
+func createPlayOptions(yOpts yamlPlayOptions) PlayOptions {
+	return PlayOptions{
+		Limit:       yOpts.Limit,
+		Tags:        yOpts.Tags,
+		SkipTags:    yOpts.SkipTags,
+		Forks:       yOpts.Forks,
+		StartAtTask: yOpts.StartAtTask,
+	}
+}
+
+//IsEmpty returns true if no option has been specified
+func (r PlayOptions) IsEmpty() bool {
+	return r.Limit == "" && len(r.Tags) == 0 && len(r.SkipTags) == 0 && r.Forks == 0 && r.StartAtTask == ""
+}
+
+//Override returns the options overridden by the ones specified into the given options
+func (r PlayOptions) Override(with PlayOptions) PlayOptions {
+	res := r
+	if with.Limit != "" {
+		res.Limit = with.Limit
+	}
+	if len(with.Tags) > 0 {
+		res.Tags = with.Tags
+	}
+	if len(with.SkipTags) > 0 {
+		res.SkipTags = with.SkipTags
+	}
+	if with.Forks != 0 {
+		res.Forks = with.Forks
+	}
+	if with.StartAtTask != "" {
+		res.StartAtTask = with.StartAtTask
+	}
+	return res
+}
+
+func (r PlayOptions) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	if r.Forks < 0 {
+		vErrs.addError(errors.New("the number of forks cannot be negative"), loc.appendPath("forks"))
+	}
+	return vErrs
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayOptions(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/play_options.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+
+	assert.Equal(t, PlayOptions{Tags: []string{"config"}, SkipTags: []string{"restart"}, Forks: -1}, env.Stacks["app"].Options)
+	assert.Equal(t, PlayOptions{Limit: "managers", Forks: 5, StartAtTask: "Dump the database"}, env.Tasks["backup"].Options)
+	assert.True(t, env.Tasks["notify"].Options.IsEmpty())
+
+	// The options are kept by the resolved hooks
+	task, e := TaskRef{ref: "backup"}.Resolve(env)
+	assert.Nil(t, e)
+	assert.Equal(t, "managers", task.Options.Limit)
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "the number of forks cannot be negative", "stacks.app.options.forks"))
+}
+
+func TestPlayOptionsOverride(t *testing.T) {
+	defaults := PlayOptions{Limit: "managers", Tags: []string{"config"}, Forks: 5}
+	res := defaults.Override(PlayOptions{Limit: "node-3", SkipTags: []string{"restart"}})
+	assert.Equal(t, PlayOptions{Limit: "node-3", Tags: []string{"config"}, SkipTags: []string{"restart"}, Forks: 5}, res)
+	assert.Equal(t, defaults, defaults.Override(PlayOptions{}))
+	assert.True(t, PlayOptions{}.IsEmpty())
+	assert.False(t, res.IsEmpty())
+}
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"sort"
+)
+
+type (
+	// PolicyRule represents an organisation rule the environment must comply with.
+	//
+	// Policy rules are evaluated each time an environment is validated, any
+	// violation is reported as a ValidationError holding the rule id and
+	// the rule severity.
+	PolicyRule struct {
+		// Id identifies the rule into the validation errors
+		Id string
+		// Severity specifies if a violation is an Error or a Warning
+		Severity ErrorType
+		// Check evaluates the rule against the environment
+		Check PolicyCheck
+		// checkDocument evaluates a declarative rule against the policy document
+		// of the environment, shared by all the rules of a validation
+		checkDocument func(doc map[string]interface{}) []PolicyViolation
+	}
+
+	// PolicyCheck returns the violations of a rule by the environment
+	PolicyCheck func(env Environment) []PolicyViolation
+
+	// PolicyViolation represents a place, within the environment, not
+	// complying with a policy rule
+	PolicyViolation struct {
+		// Path is the location of the violation into the descriptor
+		Path string
+		// Message is a human readable explanation of the violation
+		Message string
+	}
+)
+
+//WithPolicyRules returns a copy of the environment evaluating the given rules,
+//along with its own ones, on each validation
+func (r Environment) WithPolicyRules(rules ...PolicyRule) Environment {
+	policies := make([]PolicyRule, 0, len(r.policies)+len(rules))
+	policies = append(policies, r.policies...)
+	r.policies = append(policies, rules...)
+	return r
+}
+
+//PolicyRules returns the rules evaluated on each validation of the environment
+func (r Environment) PolicyRules() []PolicyRule {
+	return append([]PolicyRule{}, r.policies...)
+}
+
+// validatePolicies evaluates the rules of the environment against it
+func validatePolicies(e Environment) ValidationErrors {
+	vErrs := ValidationErrors{}
+	rules := e.PolicyRules()
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Id < rules[j].Id
+	})
+	var doc map[string]interface{}
+	for _, rule := range rules {
+		var violations []PolicyViolation
+		switch {
+		case rule.checkDocument != nil:
+			if doc == nil {
+				doc = policyDocument(e)
+			}
+			violations = rule.checkDocument(doc)
+		case rule.Check != nil:
+			violations = rule.Check(e)
+		}
+		for _, v := range violations {
+			vErrs.Errors = append(vErrs.Errors, ValidationError{
+				ErrorType: rule.Severity,
+				Location:  DescriptorLocation{Descriptor: e.loc.Descriptor, Path: v.Path},
+				Message:   v.Message,
+				Rule:      rule.Id,
+			})
+		}
+	}
+	return vErrs
+}
+
+// policyDocument returns the effective model as a tree following the layout
+// of the descriptor, this is the tree evaluated by the declarative rules.
+func policyDocument(e Environment) map[string]interface{} {
+	components := make(map[string]interface{})
+	for id, c := range e.Platform.Components {
+		repo := ""
+		if c.Repository.Loc != nil {
+			repo = c.Repository.Loc.String()
+		}
+		components[id] = map[string]interface{}{
+			"repository": repo,
+			"ref":        c.Repository.Ref,
+		}
+	}
+
+	providers := make(map[string]interface{})
+	for name, p := range e.Providers {
+		providers[name] = map[string]interface{}{
+			"component": p.ComponentId(),
+			"params":    map[string]interface{}(p.Parameters()),
+			"env":       map[string]string(p.EnvVars()),
+			"proxy":     policyProxy(p.Proxy()),
+		}
+	}
+
+	nodes := make(map[string]interface{})
+	for name, n := range e.NodeSets {
+		provider := map[string]interface{}{"name": n.Provider.ref}
+		if p, err := n.Provider.Resolve(e); err == nil {
+			provider["params"] = map[string]interface{}(p.Parameters())
+			provider["env"] = map[string]string(p.EnvVars())
+			provider["proxy"] = policyProxy(p.Proxy())
+		}
+		orchestrator := make(map[string]interface{})
+		if o, err := n.Orchestrator.Resolve(e); err == nil {
+			orchestrator["params"] = map[string]interface{}(o.Parameters())
+			orchestrator["env"] = map[string]string(o.EnvVars())
+		}
+		nodes[name] = map[string]interface{}{
+			"instances":    n.Instances,
+			"labels":       map[string]string(n.Labels),
+			"provider":     provider,
+			"orchestrator": orchestrator,
+		}
+	}
+
+	stacks := make(map[string]interface{})
+	for name, s := range e.Stacks {
+		stacks[name] = map[string]interface{}{
+			"component":    s.ComponentId(),
+			"dependencies": s.Dependencies,
+			"params":       map[string]interface{}(s.Parameters()),
+			"env":          map[string]string(s.EnvVars()),
+		}
+	}
+
+	tasks := make(map[string]interface{})
+	for name, t := range e.Tasks {
+		tasks[name] = map[string]interface{}{
+			"component": t.ComponentId(),
+			"playbook":  t.Playbook,
+			"params":    map[string]interface{}(t.Parameters()),
+			"env":       map[string]string(t.EnvVars()),
+		}
+	}
+
+	return map[string]interface{}{
+		"name":      e.QName.Name,
+		"qualifier": e.QName.Qualifier,
+		"ekara": map[string]interface{}{
+			"components": components,
+		},
+		"orchestrator": map[string]interface{}{
+			"component": e.Orchestrator.ComponentId(),
+			"params":    map[string]interface{}(e.Orchestrator.Parameters()),
+			"env":       map[string]string(e.Orchestrator.EnvVars()),
+		},
+		"providers": providers,
+		"nodes":     nodes,
+		"stacks":    stacks,
+		"tasks":     tasks,
+	}
+}
+
+func policyProxy(p Proxy) map[string]interface{} {
+	res := make(map[string]interface{})
+	if p.Http != "" {
+		res["http_proxy"] = p.Http
+	}
+	if p.Https != "" {
+		res["https_proxy"] = p.Https
+	}
+	if p.NoProxy != "" {
+		res["no_proxy"] = p.NoProxy
+	}
+	return res
+}
@@ -0,0 +1,295 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	policyExists     = "exists"
+	policyAbsent     = "absent"
+	policyEq         = "eq"
+	policyNe         = "ne"
+	policyLt         = "lt"
+	policyLe         = "le"
+	policyGt         = "gt"
+	policyGe         = "ge"
+	policyIn         = "in"
+	policyNotIn      = "notin"
+	policyMatches    = "matches"
+	policyNotMatches = "notmatches"
+)
+
+type (
+	// yaml tag for a declarative policy rules file
+	yamlPolicy struct {
+		Rules []yamlPolicyRule
+	}
+
+	// yaml tag for a declarative policy rule
+	yamlPolicyRule struct {
+		// The rule identifier
+		Id string
+		// The rule severity: "error" or "warning", defaults to "error"
+		Severity string `yaml:",omitempty"`
+		// The message reported on violation, a default one is built if not specified
+		Message string `yaml:",omitempty"`
+		// The path of the checked values into the model, "*" matches any key
+		Path string
+		// The comparison operator
+		Operator string
+		// The value to compare with
+		Value interface{} `yaml:",omitempty"`
+	}
+
+	// policyMatch represents a value located by a rule path into the model
+	policyMatch struct {
+		path  string
+		value interface{}
+		found bool
+	}
+)
+
+// ParsePolicyRules parses a declarative policy rules file.
+//
+// Each rule locates values into the effective model through a dot separated
+// path, where "*" matches any key, and compares them using the operator:
+//
+//  exists, absent          the value must be (or must not be) defined
+//  eq, ne                  equality with the rule value
+//  lt, le, gt, ge          numerical comparison with the rule value
+//  in, notin               membership into the rule values list
+//  matches, notmatches     match of the rule value as regular expression
+//
+// Except for "exists", rules only apply on the defined values.
+//
+// Example:
+//  rules:
+//    - id: max-instances
+//      path: nodes.*.instances
+//      operator: le
+//      value: 10
+func ParsePolicyRules(path string) ([]PolicyRule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	yP := yamlPolicy{}
+	err = yaml.Unmarshal(b, &yP)
+	if err != nil {
+		return nil, fmt.Errorf("yaml error in %s : %s", path, err.Error())
+	}
+	res := make([]PolicyRule, 0, len(yP.Rules))
+	for i, yR := range yP.Rules {
+		r, err := createPolicyRule(yR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy rule %d in %s: %s", i, path, err.Error())
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func createPolicyRule(yR yamlPolicyRule) (PolicyRule, error) {
+	r := PolicyRule{Id: yR.Id}
+	if yR.Id == "" {
+		return r, fmt.Errorf("missing rule id")
+	}
+	if yR.Path == "" {
+		return r, fmt.Errorf("missing path in rule %s", yR.Id)
+	}
+
+	switch strings.ToLower(yR.Severity) {
+	case "", "error":
+		r.Severity = Error
+	case "warning":
+		r.Severity = Warning
+	default:
+		return r, fmt.Errorf("unknown severity %s in rule %s", yR.Severity, yR.Id)
+	}
+
+	compare, err := policyComparison(yR.Operator, yR.Value)
+	if err != nil {
+		return r, fmt.Errorf("%s in rule %s", err.Error(), yR.Id)
+	}
+
+	segments := strings.Split(yR.Path, ".")
+	r.checkDocument = func(doc map[string]interface{}) []PolicyViolation {
+		violations := make([]PolicyViolation, 0)
+		for _, m := range resolvePolicyPath(doc, segments, "") {
+			if compare(m) {
+				continue
+			}
+			msg := yR.Message
+			if msg == "" {
+				msg = fmt.Sprintf("%s must be %s", yR.Path, yR.Operator)
+				if yR.Value != nil {
+					msg = msg + fmt.Sprintf(" %v", yR.Value)
+				}
+			}
+			if m.found {
+				msg = msg + fmt.Sprintf(" (found: %v)", m.value)
+			}
+			violations = append(violations, PolicyViolation{Path: m.path, Message: msg})
+		}
+		return violations
+	}
+	r.Check = func(env Environment) []PolicyViolation {
+		return r.checkDocument(policyDocument(env))
+	}
+	return r, nil
+}
+
+// policyComparison returns the function telling if a matched value complies
+// with the operator and the rule value.
+func policyComparison(operator string, value interface{}) (func(m policyMatch) bool, error) {
+	switch strings.ToLower(operator) {
+	case policyExists:
+		return func(m policyMatch) bool {
+			return m.found && m.value != nil && fmt.Sprintf("%v", m.value) != ""
+		}, nil
+	case policyAbsent:
+		return func(m policyMatch) bool {
+			return !m.found
+		}, nil
+	case policyEq, policyNe:
+		eq := strings.ToLower(operator) == policyEq
+		return func(m policyMatch) bool {
+			return !m.found || policyEquals(m.value, value) == eq
+		}, nil
+	case policyLt, policyLe, policyGt, policyGe:
+		ref, ok := policyNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires a numerical value", operator)
+		}
+		op := strings.ToLower(operator)
+		return func(m policyMatch) bool {
+			if !m.found {
+				return true
+			}
+			v, ok := policyNumber(m.value)
+			if !ok {
+				return false
+			}
+			switch op {
+			case policyLt:
+				return v < ref
+			case policyLe:
+				return v <= ref
+			case policyGt:
+				return v > ref
+			default:
+				return v >= ref
+			}
+		}, nil
+	case policyIn, policyNotIn:
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires a list of values", operator)
+		}
+		in := strings.ToLower(operator) == policyIn
+		return func(m policyMatch) bool {
+			if !m.found {
+				return true
+			}
+			for _, v := range values {
+				if policyEquals(m.value, v) {
+					return in
+				}
+			}
+			return !in
+		}, nil
+	case policyMatches, policyNotMatches:
+		re, err := regexp.Compile(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, err
+		}
+		matches := strings.ToLower(operator) == policyMatches
+		return func(m policyMatch) bool {
+			return !m.found || re.MatchString(fmt.Sprintf("%v", m.value)) == matches
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", operator)
+}
+
+func policyEquals(a, b interface{}) bool {
+	na, okA := policyNumber(a)
+	nb, okB := policyNumber(b)
+	if okA && okB {
+		return na == nb
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+func policyNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// resolvePolicyPath locates the values matching the path segments into the
+// given tree. A segment not found produces a single match flagged as not found.
+func resolvePolicyPath(v interface{}, segments []string, prefix string) []policyMatch {
+	if len(segments) == 0 {
+		return []policyMatch{{path: prefix, value: v, found: true}}
+	}
+	seg := segments[0]
+	join := func(s string) string {
+		if prefix == "" {
+			return s
+		}
+		return prefix + "." + s
+	}
+	notFound := []policyMatch{{path: join(strings.Join(segments, "."))}}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]interface{})
+		for _, k := range rv.MapKeys() {
+			ks := fmt.Sprintf("%v", k.Interface())
+			keys = append(keys, ks)
+			values[ks] = rv.MapIndex(k).Interface()
+		}
+		if seg == "*" {
+			sort.Strings(keys)
+			res := make([]policyMatch, 0)
+			for _, k := range keys {
+				res = append(res, resolvePolicyPath(values[k], segments[1:], join(k))...)
+			}
+			return res
+		}
+		if val, ok := values[seg]; ok {
+			return resolvePolicyPath(val, segments[1:], join(seg))
+		}
+	case reflect.Slice, reflect.Array:
+		if seg == "*" {
+			res := make([]policyMatch, 0)
+			for i := 0; i < rv.Len(); i++ {
+				res = append(res, resolvePolicyPath(rv.Index(i).Interface(), segments[1:], fmt.Sprintf("%s[%d]", prefix, i))...)
+			}
+			return res
+		}
+		if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < rv.Len() {
+			return resolvePolicyPath(rv.Index(i).Interface(), segments[1:], fmt.Sprintf("%s[%d]", prefix, i))
+		}
+	}
+	return notFound
+}
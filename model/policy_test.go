@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPolicyEnvironment(t *testing.T) Environment {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/policy/env.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+	return env
+}
+
+func TestPolicyDeclarativeRules(t *testing.T) {
+	rules, err := ParsePolicyRules("./testdata/yaml/policy/rules.yaml")
+	assert.Nil(t, err)
+	assert.Len(t, rules, 4)
+
+	vErrs := validatePolicies(testPolicyEnvironment(t).WithPolicyRules(rules...))
+	assert.Equal(t, 3, len(vErrs.Errors))
+	assert.True(t, vErrs.contains(Error, "nodes.*.instances must be le 10 (found: 12)", "nodes.workers.instances"))
+	assert.True(t, vErrs.contains(Warning, "node sets must have an owner", "nodes.workers.labels.owner"))
+	assert.True(t, vErrs.contains(Error, "ekara.components.*.repository must be notmatches ^https://forbidden.org/ (found: https://forbidden.org/aws-provider)", "ekara.components.aws.repository"))
+	for _, vErr := range vErrs.locate("node sets must have an owner") {
+		assert.Equal(t, "owner-label", vErr.Rule)
+	}
+}
+
+func TestPolicyFunctionRule(t *testing.T) {
+	rule := PolicyRule{
+		Id:       "single-provider",
+		Severity: Error,
+		Check: func(env Environment) []PolicyViolation {
+			if len(env.Providers) > 1 {
+				return []PolicyViolation{{Path: "providers", Message: "only one provider is allowed"}}
+			}
+			return nil
+		},
+	}
+
+	env := testPolicyEnvironment(t)
+	assert.False(t, validatePolicies(env).HasErrors())
+	env.Providers["other"] = Provider{Name: "other"}
+	assert.False(t, validatePolicies(env).HasErrors())
+
+	// The rules are only evaluated by the environments holding them
+	env = testPolicyEnvironment(t).WithPolicyRules(rule)
+	assert.False(t, validatePolicies(env).HasErrors())
+	assert.Len(t, testPolicyEnvironment(t).PolicyRules(), 0)
+
+	env.Providers["other"] = Provider{Name: "other"}
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "only one provider is allowed", "providers"))
+	assert.Contains(t, vErrs.Error(), "Error[single-provider]: only one provider is allowed")
+}
+
+func TestPolicyInvalidRules(t *testing.T) {
+	_, err := createPolicyRule(yamlPolicyRule{Id: "r1", Path: "nodes", Operator: "unknown"})
+	assert.NotNil(t, err)
+	_, err = createPolicyRule(yamlPolicyRule{Id: "r1", Path: "nodes.*.instances", Operator: "lt", Value: "ten"})
+	assert.NotNil(t, err)
+	_, err = createPolicyRule(yamlPolicyRule{Id: "r1", Path: "nodes", Operator: "exists", Severity: "fatal"})
+	assert.NotNil(t, err)
+	_, err = createPolicyRule(yamlPolicyRule{Path: "nodes", Operator: "exists"})
+	assert.NotNil(t, err)
+}
@@ -11,6 +11,8 @@ type (
 	Provider struct {
 		// The Name of the provider
 		Name string
+		// The kind of the provider, empty for the providers held by a component
+		Kind string
 		// The component containing the provider
 		cRef componentRef
 		// The provider parameters
@@ -19,6 +21,8 @@ type (
 		envVars EnvVars
 		// The provider proxy
 		proxy Proxy
+		// The bastion through which the hosts of the provider are connected
+		bastion Bastion
 	}
 
 	//Providers lists all the providers required to build the environment
@@ -31,15 +35,22 @@ func createProviders(yamlEnv yamlEnvironment) Providers {
 	for name, yamlProvider := range yamlEnv.Providers {
 		res[name] = Provider{
 			Name:    name,
+			Kind:    yamlProvider.Kind,
 			cRef:    componentRef{ref: yamlProvider.Component},
 			params:  CreateParameters(yamlProvider.Params),
 			envVars: CreateEnvVars(yamlProvider.Env),
 			proxy:   createProxy(yamlProvider.Proxy),
+			bastion: createBastion(yamlProvider.Bastion),
 		}
 	}
 	return res
 }
 
+//IsStatic returns true if the provider uses the existing hosts declared into the node sets
+func (p Provider) IsStatic() bool {
+	return p.Kind == StaticProviderKind
+}
+
 func (p Provider) DescType() string {
 	return "Provider"
 }
@@ -60,6 +71,11 @@ func (p Provider) Proxy() Proxy {
 	return p.proxy
 }
 
+//Bastion returns the bastion through which the hosts of the provider are connected
+func (p Provider) Bastion() Bastion {
+	return p.bastion
+}
+
 func (p Provider) ComponentId() string {
 	return p.cRef.ComponentId()
 }
@@ -69,12 +85,16 @@ func (p Provider) Component(model interface{}) (componentizer.Component, error)
 }
 
 func (p *Provider) merge(with Provider) {
+	if with.Kind != "" {
+		p.Kind = with.Kind
+	}
 	if with.cRef.ref != "" {
 		p.cRef = with.cRef
 	}
 	p.params = p.params.Override(with.params)
 	p.envVars = p.envVars.Override(with.envVars)
 	p.proxy = p.proxy.override(with.proxy)
+	p.bastion = p.bastion.override(with.bastion)
 }
 
 func (r *Providers) merge(with Providers) {
@@ -95,3 +115,18 @@ func (r Providers) validate(e Environment, loc DescriptorLocation) ValidationErr
 	}
 	return vErrs
 }
+
+func (p Provider) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	switch p.Kind {
+	case "":
+	case StaticProviderKind:
+		if p.cRef.ref != "" {
+			vErrs.addError(errors.New("a static provider cannot be held by a component"), loc.appendPath("component"))
+		}
+	default:
+		vErrs.addError(errors.New("unknown provider kind: "+p.Kind), loc.appendPath("kind"))
+	}
+	vErrs.merge(validate(e, loc.appendPath("bastion"), p.bastion))
+	return vErrs
+}
@@ -38,10 +38,12 @@ func (r ProviderRef) Resolve(model interface{}) (Provider, error) {
 	provider := model.(Environment).Providers[r.ref]
 	return Provider{
 		Name:    provider.Name,
+		Kind:    provider.Kind,
 		cRef:    provider.cRef,
 		params:  provider.params.Override(r.params),
 		envVars: provider.envVars.Override(r.envVars),
 		proxy:   provider.proxy.override(r.proxy),
+		bastion: provider.bastion,
 	}, nil
 }
 
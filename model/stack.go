@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/GroupePSA/componentizer"
+	"strings"
 )
 
 type (
@@ -25,6 +26,10 @@ type (
 		Copies Copies
 		// The hooks linked to the stack lifecycle events
 		Hooks StackHooks
+		// The custom playbook used to deploy the stack
+		Playbook string
+		// The options used to launch the playbooks of the stack
+		Options PlayOptions
 	}
 
 	StackHooks struct {
@@ -136,7 +141,13 @@ func (stacks Stacks) sorted() ([]Stack, error) {
 	}
 	res, ok := g.sort()
 	if !ok {
-		return result, fmt.Errorf("A cyclic dependency in stacks has been detected")
+		// The graph edges go from the dependency to the dependent stack, the
+		// cycle is reversed to read as "depends on"
+		cycle := g.cycle()
+		for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+			cycle[i], cycle[j] = cycle[j], cycle[i]
+		}
+		return result, fmt.Errorf("A cyclic dependency in stacks has been detected: %s", strings.Join(cycle, " -> "))
 	}
 	for _, val := range res {
 		if stack, ok := stacks[val]; ok {
@@ -157,6 +168,8 @@ func createStacks(from component, yamlEnv yamlEnvironment) Stacks {
 			params:       CreateParameters(yamlStack.Params),
 			envVars:      CreateEnvVars(yamlStack.Env),
 			Copies:       createCopies(yamlStack.Copies),
+			Playbook:     yamlStack.Playbook,
+			Options:      createPlayOptions(yamlStack.Options),
 		}
 
 		// Build hooks
@@ -176,6 +189,10 @@ func (s *Stack) merge(with Stack) {
 	s.envVars = s.envVars.Override(with.envVars)
 	s.Copies = s.Copies.override(with.Copies)
 	s.Hooks.merge(with.Hooks)
+	if with.Playbook != "" {
+		s.Playbook = with.Playbook
+	}
+	s.Options = s.Options.Override(with.Options)
 }
 
 func (s *StackHooks) merge(with StackHooks) {
@@ -238,6 +255,7 @@ func (s Stack) validate(e Environment, loc DescriptorLocation) ValidationErrors
 	}
 	vErrs.merge(validate(e, loc.appendPath("copies"), s.Copies))
 	vErrs.merge(validate(e, loc.appendPath("hooks"), s.Hooks))
+	vErrs.merge(validate(e, loc.appendPath("options"), s.Options))
 	if len(s.Dependencies) > 0 {
 		for _, dep := range s.Dependencies {
 			if _, ok := e.Stacks[dep]; !ok {
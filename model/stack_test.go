@@ -271,7 +271,9 @@ func TestStacksCyclicDependencies(t *testing.T) {
 
 	assert.Len(t, sts, 3)
 	_, err := sts.sorted()
-	assert.NotNil(t, err)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "A cyclic dependency in stacks has been detected: 1 -> 3 -> 2 -> 1", err.Error())
+	}
 
 	//Check that the original Stacks has been untouched
 	assert.Len(t, sts, 3)
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	//StaticProviderKind is the kind of the built-in provider using existing hosts,
+	//declared into the node sets, instead of creating them
+	StaticProviderKind = "static"
+)
+
+type (
+	//StaticHost is an existing host of a node set using the static provider
+	StaticHost struct {
+		// Name is the name of the host into the inventory, its address by default
+		Name string
+		// Address is the address used to connect the host
+		Address string
+		// User is the user used to connect the host
+		User string
+		// Port is the SSH port used to connect the host
+		Port int
+		// Labels are the labels of the host, overriding the ones of its node set
+		Labels Labels
+		// Vars are the ansible variables of the host
+		Vars Parameters
+	}
+
+	//StaticHosts lists the existing hosts of a node set
+	StaticHosts []StaticHost
+)
+
+func createStaticHosts(yHosts []yamlStaticHost) StaticHosts {
+	res := make(StaticHosts, 0, len(yHosts))
+	for _, yH := range yHosts {
+		name := yH.Name
+		if name == "" {
+			name = yH.Address
+		}
+		res = append(res, StaticHost{
+			Name:    name,
+			Address: yH.Address,
+			User:    yH.User,
+			Port:    yH.Port,
+			Labels:  yH.Labels,
+			Vars:    CreateParameters(yH.Vars),
+		})
+	}
+	return res
+}
+
+func (r StaticHosts) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	names := make(map[string]bool)
+	for i, h := range r {
+		if h.Address == "" {
+			vErrs.addError(errors.New("empty host address"), loc.appendIndex(i).appendPath("address"))
+			continue
+		}
+		if names[h.Name] {
+			vErrs.addError(fmt.Errorf("duplicate host: %s", h.Name), loc.appendIndex(i).appendPath("name"))
+		}
+		names[h.Name] = true
+		if h.Port < 0 {
+			vErrs.addError(errors.New("the port cannot be negative"), loc.appendIndex(i).appendPath("port"))
+		}
+	}
+	return vErrs
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticProvider(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/static_provider.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+
+	assert.True(t, env.Providers["metal"].IsStatic())
+	assert.False(t, env.Providers["aws"].IsStatic())
+	assert.True(t, env.NodeSets["db"].IsStatic(env))
+	assert.False(t, env.NodeSets["cloud"].IsStatic(env))
+
+	// The number of hosts is the default number of instances
+	db := env.NodeSets["db"]
+	assert.Equal(t, 2, db.Instances)
+	assert.Equal(t, StaticHosts{
+		{Name: "10.0.0.1", Address: "10.0.0.1", User: "centos", Labels: Labels{"disk": "ssd"}, Vars: Parameters{"db_primary": true}},
+		{Name: "db2", Address: "10.0.0.2", Port: 2222, Vars: Parameters{}},
+	}, db.Hosts)
+
+	// The kind is kept by the resolved provider
+	p, e := db.Provider.Resolve(env)
+	assert.Nil(t, e)
+	assert.True(t, p.IsStatic())
+
+	vErrs := env.Validate()
+	assert.True(t, vErrs.contains(Error, "a static provider cannot be held by a component", "providers.wrong.component"))
+	assert.True(t, vErrs.contains(Error, "unknown provider kind: baremetal", "providers.unknown.kind"))
+	assert.True(t, vErrs.contains(Error, "the node set of a static provider requires hosts", "nodes.empty.hosts"))
+	assert.True(t, vErrs.contains(Error, "duplicate host: 10.0.1.1", "nodes.mismatch.hosts[1].name"))
+	assert.True(t, vErrs.contains(Error, "empty host address", "nodes.mismatch.hosts[2].address"))
+	assert.True(t, vErrs.contains(Error, "only the node sets of a static provider can declare hosts", "nodes.cloud.hosts"))
+	assert.False(t, vErrs.contains(Error, "the instances must match the number of hosts of a static provider", "nodes.db.instances"))
+}
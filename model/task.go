@@ -2,7 +2,6 @@ package model
 
 import (
 	"errors"
-	"fmt"
 	"github.com/GroupePSA/componentizer"
 	"strings"
 )
@@ -18,6 +17,8 @@ type (
 		Name string
 		// The playbook to execute
 		Playbook string
+		// The options used to launch the playbook
+		Options PlayOptions
 		// The task parameters
 		params Parameters
 		// The task environment variables
@@ -35,7 +36,9 @@ type (
 		Execute Hook
 	}
 
-	circularRefTracking map[string]interface{}
+	// circularRefTracking holds the ordered chain of task names walked while
+	// following hooks
+	circularRefTracking []string
 )
 
 func (r Task) DescType() string {
@@ -81,6 +84,7 @@ func (r *Task) merge(with Task) {
 	if with.Playbook != "" {
 		r.Playbook = with.Playbook
 	}
+	r.Options = r.Options.Override(with.Options)
 	r.Hooks.Execute.merge(with.Hooks.Execute)
 	r.params = r.params.Override(with.params)
 	r.envVars = r.envVars.Override(with.envVars)
@@ -92,6 +96,7 @@ func createTasks(from component, yamlEnv yamlEnvironment) Tasks {
 		res[name] = Task{
 			Name:     name,
 			Playbook: yamlTask.Playbook,
+			Options:  createPlayOptions(yamlTask.Options),
 			cRef:     componentRef{ref: yamlTask.Component},
 			selfRef:  componentRef{ref: from.Id},
 			params:   CreateParameters(yamlTask.Params),
@@ -116,11 +121,35 @@ func (r *Tasks) merge(with Tasks) {
 }
 
 func (r circularRefTracking) String() string {
-	builder := strings.Builder{}
-	for key := range r {
-		builder.WriteString(fmt.Sprintf("%s -> ", key))
+	return strings.Join(r, " -> ")
+}
+
+func (r circularRefTracking) indexOf(ref string) int {
+	for i, name := range r {
+		if name == ref {
+			return i
+		}
+	}
+	return -1
+}
+
+// canonical returns the cycle rotated to start on its smallest task name, this
+// way a cycle reached from several hooks is always described the same way.
+func (r circularRefTracking) canonical() circularRefTracking {
+	if len(r) < 2 {
+		return r
+	}
+	loop := r[:len(r)-1]
+	first := 0
+	for i, name := range loop {
+		if name < loop[first] {
+			first = i
+		}
 	}
-	return builder.String()
+	res := make(circularRefTracking, 0, len(r))
+	res = append(res, loop[first:]...)
+	res = append(res, loop[:first]...)
+	return append(res, loop[first])
 }
 
 //HasTasks returns true if the hook contains at least one task reference
@@ -142,6 +171,7 @@ func (r Task) validate(e Environment, loc DescriptorLocation) ValidationErrors {
 	if len(r.Playbook) == 0 {
 		vErrs.addError(errors.New("no playbook specified"), loc.appendPath("playbook"))
 	}
+	vErrs.merge(validate(e, loc.appendPath("options"), r.Options))
 	vErrs.merge(validate(e, loc.appendPath("hooks"), r.Hooks))
 	return vErrs
 }
@@ -1,7 +1,6 @@
 package model
 
 import (
-	"errors"
 	"fmt"
 )
 
@@ -47,6 +46,7 @@ func (r TaskRef) Resolve(env Environment) (Task, error) {
 		Name:     task.Name,
 		cRef:     task.cRef,
 		Playbook: task.Playbook,
+		Options:  task.Options,
 		Hooks:    task.Hooks,
 		params:   task.params.Override(r.params),
 		envVars:  task.envVars.Override(r.envVars)}, nil
@@ -61,11 +61,33 @@ func createTaskRef(tRef yamlTaskRef) TaskRef {
 	}
 }
 
-func checkCircularRefs(taskRefs []TaskRef, alreadyEncountered *circularRefTracking) error {
+// checkCircularRefs follows the given task references, and recursively the
+// execute hooks of the referenced tasks, returning the cycles encountered.
+//
+// Each returned cycle starts and ends with the same task name. The explored
+// map holds the tasks already fully walked, which cannot lead to a new cycle.
+func checkCircularRefs(e Environment, taskRefs []TaskRef, alreadyEncountered circularRefTracking, explored map[string]bool) []circularRefTracking {
+	var cycles []circularRefTracking
 	for _, taskRef := range taskRefs {
-		if _, ok := (*alreadyEncountered)[taskRef.ref]; ok {
-			return errors.New("circular task reference: " + alreadyEncountered.String() + taskRef.ref)
+		if i := alreadyEncountered.indexOf(taskRef.ref); i >= 0 {
+			cycle := make(circularRefTracking, 0, len(alreadyEncountered)-i+1)
+			cycle = append(cycle, alreadyEncountered[i:]...)
+			cycles = append(cycles, append(cycle, taskRef.ref))
+			continue
 		}
+		if explored[taskRef.ref] {
+			continue
+		}
+		task, ok := e.Tasks[taskRef.ref]
+		if !ok {
+			// Unknown tasks are reported by the reference validation
+			continue
+		}
+		walked := make(circularRefTracking, 0, len(alreadyEncountered)+1)
+		walked = append(walked, alreadyEncountered...)
+		walked = append(walked, taskRef.ref)
+		cycles = append(cycles, checkCircularRefs(e, task.Hooks.Execute.refs(), walked, explored)...)
+		explored[taskRef.ref] = true
 	}
-	return nil
+	return cycles
 }
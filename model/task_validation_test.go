@@ -19,3 +19,25 @@ func TestValidationTasksNoPlayBook(t *testing.T) {
 	assert.Equal(t, 1, len(vErrs.Errors))
 	assert.True(t, vErrs.contains(Error, "no playbook specified", "tasks.task1.playbook"))
 }
+
+// Test loading tasks referencing each other through their hooks.
+//
+// The validation must complain once per cycle, even if the cycle is reachable
+// from several hooks
+//
+//- Error: circular task reference: task1 -> task2 -> task3 -> task1 @tasks.task1.hooks.execute
+//- Error: circular task reference: task4 -> task4 @tasks.task4.hooks.execute
+//
+func TestValidationTasksCircularHooks(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/grammar/task_circular_hook.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+	vErrs := env.Validate()
+	assert.True(t, vErrs.HasErrors())
+	assert.False(t, vErrs.HasWarnings())
+	assert.Equal(t, 2, len(vErrs.Errors))
+	assert.True(t, vErrs.contains(Error, "circular task reference: task1 -> task2 -> task3 -> task1", "tasks.task1.hooks.execute"))
+	assert.True(t, vErrs.contains(Error, "circular task reference: task4 -> task4", "tasks.task4.hooks.execute"))
+}
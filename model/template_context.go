@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/GroupePSA/componentizer"
+	"github.com/ekara-platform/engine/secret"
 	json "github.com/json-iterator/go"
 	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -31,7 +36,40 @@ type (
 			EnvVars EnvVars
 		}
 		Runtime Parameters
+		// Inventory gives access to the hosts of the environment, if available
+		Inventory Inventory
+		// lenient allows missing keys to be rendered as "<no value>"
+		lenient bool
+		// descriptors lists the descriptors templated so far, checked once all
+		// the components are fetched
+		descriptors []templatedDescriptor
+		// secretKey is the key used to decrypt the secrets
+		secretKey *secret.Key
+		// envAllowlist lists the environment variables readable by the "env" function
+		envAllowlist []string
 	}
+
+	// templatedDescriptor is a descriptor templated while fetching the components
+	templatedDescriptor struct {
+		name string
+		path string
+	}
+
+	// TemplateError represents a failure while templating some content
+	TemplateError struct {
+		// Template is the name of the failing template, as "type:name"
+		Template string
+		// Line is the line of the template where the failure occurred, if known
+		Line int
+		// Key is the path of the missing key, if the failure comes from a missing key
+		Key string
+		// Message is the underlying error message
+		Message string
+	}
+)
+
+var (
+	templateLocationRegexp = regexp.MustCompile(`^(\d+)(?::\d+)?: (?:executing ".*" at <(.*)>: )?(.*)$`)
 )
 
 // CreateTemplateContext Returns a template context
@@ -44,9 +82,13 @@ func CreateTemplateContext(params Parameters) *TemplateContext {
 
 func (tplC *TemplateContext) Clone(ref componentizer.ComponentRef) componentizer.TemplateContext {
 	newTplC := TemplateContext{
-		Vars:    CloneParameters(tplC.Vars),
-		Runtime: CloneParameters(tplC.Runtime),
-		Model:   tplC.Model,
+		Vars:         CloneParameters(tplC.Vars),
+		Runtime:      CloneParameters(tplC.Runtime),
+		Model:        tplC.Model,
+		Inventory:    tplC.Inventory,
+		lenient:      tplC.lenient,
+		secretKey:    tplC.secretKey,
+		envAllowlist: tplC.envAllowlist,
 	}
 	if o, ok := ref.(Describable); ok {
 		newTplC.Component.Type = o.DescType()
@@ -64,23 +106,155 @@ func (tplC *TemplateContext) Clone(ref componentizer.ComponentRef) componentizer
 	return &newTplC
 }
 
+// SetLenient specifies if missing keys are tolerated while templating the descriptors.
+//
+// By default the templating of the descriptors is strict: referring to a missing
+// key, like an undefined variable, fails CheckDescriptors instead of rendering
+// "<no value>".
+func (tplC *TemplateContext) SetLenient(lenient bool) {
+	tplC.lenient = lenient
+}
+
+// SetEnvAllowlist specifies the environment variables readable by the "env"
+// template function, none by default.
+//
+// A name ending with "*" allows all the variables starting with the
+// preceding prefix.
+func (tplC *TemplateContext) SetEnvAllowlist(names ...string) {
+	tplC.envAllowlist = append([]string{}, names...)
+}
+
+// SetSecretKey specifies the key used by the "secret" function to decrypt
+// the secrets
+func (tplC *TemplateContext) SetSecretKey(key *secret.Key) {
+	tplC.secretKey = key
+}
+
+// Execute templates the content of a component file, missing keys are always
+// tolerated there to keep working the components relying on them
 func (tplC TemplateContext) Execute(content string) (string, error) {
-	t, err := template.New(fmt.Sprintf("%s:%s", tplC.Component.Type, tplC.Component.Name)).Funcs(template.FuncMap{
-		"yaml":   toYaml,
-		"json":   toJson,
-		"indent": indent,
-	}).Parse(content)
+	return tplC.execute(fmt.Sprintf("%s:%s", tplC.Component.Type, tplC.Component.Name), content, false)
+}
+
+// executeDescriptor templates the content of a descriptor, strictly unless
+// the context is lenient
+func (tplC TemplateContext) executeDescriptor(name string, content string) (string, error) {
+	return tplC.execute(name, content, !tplC.lenient)
+}
+
+// addDescriptor records a descriptor templated while fetching the components
+func (tplC *TemplateContext) addDescriptor(name string, path string) {
+	for _, d := range tplC.descriptors {
+		if d.path == path {
+			return
+		}
+	}
+	tplC.descriptors = append(tplC.descriptors, templatedDescriptor{name: name, path: path})
+}
+
+// CheckDescriptors templates again all the descriptors parsed so far, strictly
+// unless the context is lenient, and returns the templating errors as
+// ValidationErrors located into the descriptors.
+//
+// The descriptors are templated leniently while the components are fetched,
+// as they can refer to the variables of components not fetched yet, so this
+// must be called once all the components are fetched.
+func (tplC *TemplateContext) CheckDescriptors() error {
+	vErrs := ValidationErrors{}
+	for _, d := range tplC.descriptors {
+		content, err := ioutil.ReadFile(d.path)
+		if err != nil {
+			return err
+		}
+		if _, err := tplC.executeDescriptor(d.name, string(content)); err != nil {
+			tErr, ok := err.(TemplateError)
+			if !ok {
+				return err
+			}
+			vErrs.merge(templateValidationErrors(d.path, tErr))
+		}
+	}
+	if vErrs.HasErrors() {
+		return vErrs
+	}
+	return nil
+}
+
+func (tplC TemplateContext) execute(name string, content string, strict bool) (string, error) {
+	funcs := templateFuncs()
+	funcs["secret"] = tplC.decryptSecret
+	funcs["env"] = tplC.env
+	t := template.New(name).Funcs(funcs)
+	if strict {
+		t = t.Option("missingkey=error")
+	}
+	t, err := t.Parse(content)
 	if err != nil {
-		return "", err
+		return "", createTemplateError(name, err)
 	}
 	var result bytes.Buffer
 	err = t.Execute(&result, tplC)
 	if err != nil {
-		return "", fmt.Errorf("templating error: %s", err.Error())
+		return "", createTemplateError(name, err)
 	}
 	return result.String(), nil
 }
 
+// createTemplateError extracts the line and the missing key, if any,
+// from an error returned by text/template
+func createTemplateError(name string, err error) TemplateError {
+	tErr := TemplateError{Template: name, Message: err.Error()}
+	detail := strings.TrimPrefix(err.Error(), "template: "+name+":")
+	if m := templateLocationRegexp.FindStringSubmatch(detail); m != nil {
+		tErr.Line, _ = strconv.Atoi(m[1])
+		tErr.Message = m[3]
+		if strings.HasPrefix(m[3], "map has no entry for key") {
+			tErr.Key = m[2]
+		}
+	}
+	return tErr
+}
+
+func (e TemplateError) Error() string {
+	msg := "templating error in " + e.Template
+	if e.Line > 0 {
+		msg = msg + fmt.Sprintf(" at line %d", e.Line)
+	}
+	if e.Key != "" {
+		return msg + fmt.Sprintf(": no value for the key %s", e.Key)
+	}
+	return msg + ": " + e.Message
+}
+
+// decryptSecret decrypts an encrypted value, the decrypted value is
+// marked as sensitive to never be disclosed.
+//
+// Example: {{ .Vars.password | secret }}
+func (tplC TemplateContext) decryptSecret(value string) (string, error) {
+	if tplC.secretKey == nil {
+		return "", fmt.Errorf("no secret key has been provided to decrypt the secrets")
+	}
+	v, err := secret.Decrypt(tplC.secretKey, value)
+	if err != nil {
+		return "", err
+	}
+	secret.MarkSensitive(v)
+	return v, nil
+}
+
+// env returns the value of an environment variable, only the variables
+// allowed through SetEnvAllowlist can be read.
+//
+// Example: {{ env "HOME" }}
+func (tplC TemplateContext) env(name string) (string, error) {
+	for _, allowed := range tplC.envAllowlist {
+		if allowed == name || (strings.HasSuffix(allowed, "*") && strings.HasPrefix(name, strings.TrimSuffix(allowed, "*"))) {
+			return os.Getenv(name), nil
+		}
+	}
+	return "", fmt.Errorf("the environment variable %s is not allowed into templates", name)
+}
+
 func (tplC *TemplateContext) addVars(vars Parameters) {
 	tplC.Vars = tplC.Vars.Override(vars)
 }
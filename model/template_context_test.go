@@ -3,6 +3,7 @@ package model
 import (
 	"testing"
 
+	"github.com/ekara-platform/engine/secret"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -112,3 +113,87 @@ func TestFullTemplating(t *testing.T) {
       key11: value1
 `, res)
 }
+
+func TestStrictTemplating(t *testing.T) {
+	p := CreateParameters(map[string]interface{}{
+		"info": map[string]interface{}{
+			"name": "value1",
+		},
+	})
+
+	tplC := CreateTemplateContext(p)
+	_, err := tplC.executeDescriptor("Stack:stack1", "name: {{ .Vars.info.name }}\ndesc: {{ .Vars.info.desc }}")
+	if assert.NotNil(t, err) {
+		tErr, ok := err.(TemplateError)
+		if assert.True(t, ok) {
+			assert.Equal(t, "Stack:stack1", tErr.Template)
+			assert.Equal(t, 2, tErr.Line)
+			assert.Equal(t, ".Vars.info.desc", tErr.Key)
+		}
+		assert.Equal(t, "templating error in Stack:stack1 at line 2: no value for the key .Vars.info.desc", err.Error())
+	}
+
+	// The component files tolerate the missing keys
+	tplC.Component.Type = "Stack"
+	tplC.Component.Name = "stack1"
+	res, err := tplC.Execute("desc: {{ .Vars.info.desc }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "desc: <no value>", res)
+}
+
+func TestLenientTemplating(t *testing.T) {
+	tplC := CreateTemplateContext(CreateEmptyParameters())
+	tplC.SetLenient(true)
+	res, err := tplC.executeDescriptor("descriptor", "{{ .Vars.missing }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "<no value>", res)
+
+	// The mode is kept by the clones
+	cloned := tplC.Clone(componentRef{ref: "comp"}).(*TemplateContext)
+	res, err = cloned.executeDescriptor("descriptor", "{{ .Vars.missing }}")
+	assert.Nil(t, err)
+	assert.Equal(t, "<no value>", res)
+}
+
+func TestTemplatingErrorInDescriptor(t *testing.T) {
+	yamlEnv := yamlEnvironment{}
+	tplC := CreateTemplateContext(CreateParameters(map[string]interface{}{
+		"info": map[string]interface{}{},
+	}))
+	// The descriptor is templated leniently while the components are fetched
+	err := parseNamedYaml("Component:__main__", "testdata/yaml/missing_var.yaml", tplC, &yamlEnv)
+	assert.Nil(t, err)
+
+	// The missing keys are reported once all the components are fetched
+	err = tplC.CheckDescriptors()
+	if assert.NotNil(t, err) {
+		vErrs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) && assert.Len(t, vErrs.Errors, 1) {
+			assert.Equal(t, Error, vErrs.Errors[0].ErrorType)
+			assert.Equal(t, "testdata/yaml/missing_var.yaml", vErrs.Errors[0].Location.Descriptor)
+			assert.Equal(t, "templating error in Component:__main__ at line 3: no value for the key .Vars.info.desc", vErrs.Errors[0].Message)
+		}
+	}
+}
+
+func TestSecretTemplating(t *testing.T) {
+	key, err := secret.GenerateKey()
+	assert.Nil(t, err)
+	enc, err := secret.Encrypt(key, "templated-secret")
+	assert.Nil(t, err)
+
+	tplC := CreateTemplateContext(CreateParameters(map[string]interface{}{
+		"password": enc,
+	}))
+	_, err = tplC.Execute(`{{ .Vars.password | secret }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "no secret key has been provided")
+	}
+
+	tplC.SetSecretKey(key)
+	res, err := tplC.Execute(`{{ .Vars.password | secret }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "templated-secret", res)
+	assert.True(t, secret.IsSensitive("templated-secret"))
+	assert.Equal(t, "password: ******", secret.Hide("password: templated-secret"))
+}
@@ -0,0 +1,254 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ekara-platform/engine/secret"
+	"gopkg.in/yaml.v2"
+)
+
+// templateFuncs returns the functions available into all ekara templates
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Serialization
+		"yaml":     toYaml,
+		"json":     toJson,
+		"toToml":   toToml,
+		"fromYaml": fromYaml,
+		"fromJson": fromJson,
+		// Default values
+		"default":  defaultValue,
+		"required": required,
+		"coalesce": coalesce,
+		// Strings
+		"indent":  indent,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": replace,
+		"split":   split,
+		"join":    join,
+		// Encoding
+		"b64enc": b64enc,
+		"b64dec": b64dec,
+		"sha256": sha256Sum,
+		// Dictionaries and lists
+		"dict":   dict,
+		"list":   list,
+		"hasKey": hasKey,
+		"keys":   keys,
+		// Secrets
+		"sensitive": sensitive,
+	}
+}
+
+// isEmpty returns true if the value is nil or the zero value of its type
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
+
+// defaultValue returns the value if not empty, the default one otherwise.
+//
+// As templating fails on missing keys, possibly missing values must be
+// accessed through "index".
+//
+// Example: {{ index .Vars "port" | default 8080 }}
+func defaultValue(def interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || isEmpty(v[0]) {
+		return def
+	}
+	return v[0]
+}
+
+// required fails the templating with the given message if the value is empty.
+//
+// Example: {{ index .Vars "domain" | required "the variable domain is mandatory" }}
+func required(msg string, v interface{}) (interface{}, error) {
+	if isEmpty(v) {
+		return nil, fmt.Errorf("required value missing: %s", msg)
+	}
+	return v, nil
+}
+
+// coalesce returns the first non empty value
+func coalesce(v ...interface{}) interface{} {
+	for _, val := range v {
+		if !isEmpty(val) {
+			return val
+		}
+	}
+	return nil
+}
+
+func replace(old string, new string, s string) string {
+	return strings.Replace(s, old, new, -1)
+}
+
+func split(sep string, s string) []string {
+	return strings.Split(s, sep)
+}
+
+func join(sep string, v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	if v == nil {
+		return "", nil
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join expects a list, got %T", v)
+	}
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, fmt.Sprintf("%v", rv.Index(i).Interface()))
+	}
+	return strings.Join(items, sep), nil
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 content: %s", err.Error())
+	}
+	return string(b), nil
+}
+
+func sha256Sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func toToml(v interface{}) (string, error) {
+	var b bytes.Buffer
+	err := toml.NewEncoder(&b).Encode(stringKeys(v))
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func fromYaml(s string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	err := yaml.Unmarshal([]byte(s), &res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid yaml content: %s", err.Error())
+	}
+	return stringKeys(res).(map[string]interface{}), nil
+}
+
+func fromJson(s string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	err := json.Unmarshal([]byte(s), &res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json content: %s", err.Error())
+	}
+	return res, nil
+}
+
+// dict builds a map from a list of key/value pairs.
+//
+// Example: {{ dict "host" .Vars.host "port" 80 | json }}
+func dict(v ...interface{}) (map[string]interface{}, error) {
+	if len(v)%2 != 0 {
+		return nil, fmt.Errorf("dict expects an even number of arguments, got %d", len(v))
+	}
+	res := make(map[string]interface{}, len(v)/2)
+	for i := 0; i < len(v); i += 2 {
+		k, ok := v[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", v[i])
+		}
+		res[k] = v[i+1]
+	}
+	return res, nil
+}
+
+func list(v ...interface{}) []interface{} {
+	return v
+}
+
+func hasKey(m interface{}, key string) bool {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		return false
+	}
+	for _, k := range rv.MapKeys() {
+		if fmt.Sprintf("%v", k.Interface()) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the sorted keys of a map
+func keys(m interface{}) []string {
+	res := make([]string, 0)
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		return res
+	}
+	for _, k := range rv.MapKeys() {
+		res = append(res, fmt.Sprintf("%v", k.Interface()))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// sensitive marks the value as sensitive, to be hidden from the logs and
+// the reports, and returns it unchanged.
+//
+// Example: {{ .Vars.api_credentials | sensitive }}
+func sensitive(v interface{}) interface{} {
+	secret.MarkSensitiveValues(v)
+	return v
+}
+
+// stringKeys converts recursively the maps decoded from yaml into maps
+// with string keys
+func stringKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			res[fmt.Sprintf("%v", k)] = stringKeys(val)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			res[k] = stringKeys(val)
+		}
+		return res
+	case Parameters:
+		return stringKeys(map[string]interface{}(t))
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, val := range t {
+			res[i] = stringKeys(val)
+		}
+		return res
+	}
+	return v
+}
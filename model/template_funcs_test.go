@@ -0,0 +1,152 @@
+package model
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func executeFunc(vars map[string]interface{}, content string) (string, error) {
+	tplC := CreateTemplateContext(CreateParameters(vars))
+	return tplC.Execute(content)
+}
+
+func TestTemplateDefault(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"port": 0, "host": "localhost"}, `{{ .Vars.port | default 8080 }} {{ .Vars.host | default "remote" }} {{ index .Vars "missing" | default "value" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "8080 localhost value", res)
+}
+
+func TestTemplateRequired(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"domain": "ekara.io"}, `{{ .Vars.domain | required "the domain is mandatory" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "ekara.io", res)
+
+	_, err = executeFunc(map[string]interface{}{}, `{{ index .Vars "domain" | required "the domain is mandatory" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "required value missing: the domain is mandatory")
+	}
+}
+
+func TestTemplateCoalesce(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"b": "", "c": "third"}, `{{ coalesce (index .Vars "a") .Vars.b .Vars.c "last" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "third", res)
+}
+
+func TestTemplateStrings(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"v": "  Hello World  "}, `{{ upper .Vars.v }}|{{ lower .Vars.v }}|{{ trim .Vars.v }}|{{ .Vars.v | trim | replace "World" "Ekara" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "  HELLO WORLD  |  hello world  |Hello World|Hello Ekara", res)
+}
+
+func TestTemplateSplitJoin(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"v": "a,b,c", "l": []interface{}{1, 2, 3}}, `{{ .Vars.v | split "," | join "-" }} {{ .Vars.l | join "+" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "a-b-c 1+2+3", res)
+
+	_, err = executeFunc(map[string]interface{}{"v": "a"}, `{{ .Vars.v | join "-" }}`)
+	assert.NotNil(t, err)
+}
+
+func TestTemplateBase64(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"v": "ekara"}, `{{ b64enc .Vars.v }} {{ .Vars.v | b64enc | b64dec }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "ZWthcmE= ekara", res)
+
+	_, err = executeFunc(map[string]interface{}{}, `{{ b64dec "not base64!" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid base64 content")
+	}
+}
+
+func TestTemplateSha256(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{}, `{{ sha256 "ekara" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "fddfba4582b127c57d0801cc7f3f61878a9c62c76d3da68722dad13e5a8cbb2d", res)
+}
+
+func TestTemplateToToml(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{
+		"conf": map[interface{}]interface{}{
+			"name": "ekara",
+			"server": map[interface{}]interface{}{
+				"port": 80,
+			},
+		},
+	}, `{{ .Vars.conf | toToml }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "name = \"ekara\"\n\n[server]\n  port = 80\n", res)
+}
+
+func TestTemplateFromYamlJson(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{
+		"y": "server:\n  port: 80\n",
+		"j": `{"server": {"port": 443}}`,
+	}, `{{ (.Vars.y | fromYaml).server.port }} {{ (.Vars.j | fromJson).server.port }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "80 443", res)
+
+	_, err = executeFunc(map[string]interface{}{}, `{{ fromJson "{" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid json content")
+	}
+	_, err = executeFunc(map[string]interface{}{}, `{{ fromYaml "- a" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid yaml content")
+	}
+}
+
+func TestTemplateDictList(t *testing.T) {
+	res, err := executeFunc(map[string]interface{}{"host": "h1"}, `{{ $d := dict "host" .Vars.host "port" 80 }}{{ $d.host }}:{{ $d.port }} {{ hasKey $d "port" }} {{ hasKey $d "user" }} {{ keys $d | join "," }} {{ list 1 "a" 2 | join "," }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "h1:80 true false host,port 1,a,2", res)
+
+	_, err = executeFunc(map[string]interface{}{}, `{{ dict "key" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "dict expects an even number of arguments")
+	}
+}
+
+func TestTemplateEnv(t *testing.T) {
+	os.Setenv("EKARA_TEST_ALLOWED", "allowed")
+	defer os.Unsetenv("EKARA_TEST_ALLOWED")
+	os.Setenv("EKARA_TEST_PREFIXED", "prefixed")
+	defer os.Unsetenv("EKARA_TEST_PREFIXED")
+
+	_, err := executeFunc(map[string]interface{}{}, `{{ env "EKARA_TEST_ALLOWED" }}`)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "the environment variable EKARA_TEST_ALLOWED is not allowed into templates")
+	}
+
+	tplC := CreateTemplateContext(CreateEmptyParameters())
+	tplC.SetEnvAllowlist("EKARA_TEST_ALLOWED", "EKARA_TEST_PRE*")
+	res, err := tplC.Execute(`{{ env "EKARA_TEST_ALLOWED" }} {{ env "EKARA_TEST_PREFIXED" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "allowed prefixed", res)
+
+	_, err = tplC.Execute(`{{ env "HOME" }}`)
+	assert.NotNil(t, err)
+
+	// The allowlist is kept by the clones, and only applies to its context
+	res, err = tplC.Clone(componentRef{ref: "comp"}).Execute(`{{ env "EKARA_TEST_ALLOWED" }}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "allowed", res)
+	_, err = executeFunc(map[string]interface{}{}, `{{ env "EKARA_TEST_ALLOWED" }}`)
+	assert.NotNil(t, err)
+}
+
+func TestTemplateFuncsInDescriptor(t *testing.T) {
+	os.Setenv("EKARA_TEST_QUALIFIER", "dev")
+	defer os.Unsetenv("EKARA_TEST_QUALIFIER")
+
+	yamlEnv := yamlEnvironment{}
+	tplC := CreateTemplateContext(CreateParameters(map[string]interface{}{"name": "FUNCS"}))
+	tplC.SetEnvAllowlist("EKARA_TEST_QUALIFIER")
+	err := parseYaml("testdata/yaml/funcs.yaml", tplC, &yamlEnv)
+	assert.Nil(t, err)
+	assert.Equal(t, "funcs", yamlEnv.Name)
+	assert.Equal(t, "Templated with functions", yamlEnv.Description)
+	assert.Equal(t, "dev", yamlEnv.Qualifier)
+}
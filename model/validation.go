@@ -25,6 +25,8 @@ type (
 		// Message represents a human readable message telling what need to be
 		// fixed into the descriptor to get rid of this error
 		Message string
+		// Rule identifies the policy rule which produced this error, if any
+		Rule string `json:",omitempty"`
 	}
 
 	validatable interface {
@@ -36,7 +38,11 @@ type (
 func (ve ValidationErrors) Error() string {
 	s := "Validation errors or warnings have occurred:\n"
 	for _, err := range ve.Errors {
-		s = s + "\t" + err.ErrorType.String() + ": " + err.Message + " @" + err.Location.Path + "\n\tin: " + err.Location.Descriptor + "\n\t"
+		t := err.ErrorType.String()
+		if err.Rule != "" {
+			t = t + "[" + err.Rule + "]"
+		}
+		s = s + "\t" + t + ": " + err.Message + " @" + err.Location.Path + "\n\tin: " + err.Location.Descriptor + "\n\t"
 	}
 	return s
 }
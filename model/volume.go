@@ -0,0 +1,249 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/GroupePSA/componentizer"
+)
+
+type (
+	// Volume represents a volume to create on each machine of a node set
+	Volume struct {
+		// The mounting path of the volume
+		Path string
+		// The parameters required to create the volume (typically provider dependent)
+		params Parameters
+	}
+
+	// Volumes represents the volumes of a node set
+	Volumes []Volume
+
+	// GlobalVolume represents a volume shared by the node sets mounting its path,
+	// with the content to copy into it
+	GlobalVolume struct {
+		// The mounting path of the volume
+		Path string
+		// The content to copy into the volume
+		Content []VolumeContent
+	}
+
+	// VolumeContent represents a content, held by a component, to copy into a global volume
+	VolumeContent struct {
+		// The component holding the content
+		cRef componentRef
+		// The path of the content within the component
+		Path string
+		// The path of the volume receiving the content
+		volume string
+	}
+
+	// GlobalVolumes represents all the global volumes of the environment, by path
+	GlobalVolumes map[string]GlobalVolume
+)
+
+func createVolumes(yVolumes []yamlVolume) Volumes {
+	res := make(Volumes, 0, len(yVolumes))
+	for _, yV := range yVolumes {
+		res = append(res, Volume{
+			Path:   yV.Path,
+			params: CreateParameters(yV.Params),
+		})
+	}
+	return res
+}
+
+// Parameters returns the parameters required to create the volume
+func (r Volume) Parameters() Parameters {
+	return r.params
+}
+
+// Paths returns the paths of the volumes
+func (r Volumes) Paths() []string {
+	res := make([]string, 0, len(r))
+	for _, v := range r {
+		res = append(res, v.Path)
+	}
+	return res
+}
+
+// Contains returns true if a volume is mounted on the given path
+func (r Volumes) Contains(path string) bool {
+	for _, v := range r {
+		if v.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// AsParams returns the volumes in the form expected into the parameters of
+// the provider playbooks
+func (r Volumes) AsParams() []map[string]interface{} {
+	res := make([]map[string]interface{}, 0, len(r))
+	for _, v := range r {
+		res = append(res, map[string]interface{}{
+			"path":   v.Path,
+			"params": map[string]interface{}(v.params),
+		})
+	}
+	return res
+}
+
+// merge merges the volumes by path, the parameters of the volumes already
+// defined are overridden by the ones of the merged volumes.
+//
+// Duplicated paths within the merged volumes are kept to be reported by the validation.
+func (r Volumes) merge(with Volumes) Volumes {
+	res := make(Volumes, 0, len(r)+len(with))
+	res = append(res, r...)
+	for _, v := range with {
+		merged := false
+		for i, existing := range res[:len(r)] {
+			if existing.Path == v.Path {
+				res[i].params = existing.params.Override(v.params)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (r Volumes) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	encountered := make(map[string]bool)
+	for i, v := range r {
+		if v.Path == "" {
+			vErrs.addError(errors.New("empty volume path"), loc.appendIndex(i).appendPath("path"))
+			continue
+		}
+		if encountered[v.Path] {
+			vErrs.addError(fmt.Errorf("duplicate volume path: %s", v.Path), loc.appendIndex(i).appendPath("path"))
+		}
+		encountered[v.Path] = true
+	}
+	return vErrs
+}
+
+func createGlobalVolumes(yamlEnv yamlEnvironment) GlobalVolumes {
+	res := GlobalVolumes{}
+	for path, yV := range yamlEnv.Volumes {
+		gV := GlobalVolume{
+			Path:    path,
+			Content: make([]VolumeContent, 0, len(yV.Content)),
+		}
+		for _, yC := range yV.Content {
+			gV.Content = append(gV.Content, VolumeContent{
+				cRef:   componentRef{ref: yC.Component},
+				Path:   yC.Path,
+				volume: path,
+			})
+		}
+		res[path] = gV
+	}
+	return res
+}
+
+func (r GlobalVolume) DescType() string {
+	return "Volume"
+}
+
+func (r GlobalVolume) DescName() string {
+	return r.Path
+}
+
+// merge adds the content not already defined into the volume
+func (r *GlobalVolume) merge(with GlobalVolume) {
+	for _, c := range with.Content {
+		found := false
+		for _, existing := range r.Content {
+			if existing.cRef.ref == c.cRef.ref && existing.Path == c.Path {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.Content = append(r.Content, c)
+		}
+	}
+}
+
+func (r GlobalVolume) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := ValidationErrors{}
+	mounted := false
+	for _, ns := range e.NodeSets {
+		if ns.Volumes.Contains(r.Path) {
+			mounted = true
+			break
+		}
+	}
+	if !mounted {
+		vErrs.addWarning("no node set mounts the volume: "+r.Path, loc)
+	}
+	for i, c := range r.Content {
+		vErrs.merge(validate(e, loc.appendPath("content").appendIndex(i), c))
+	}
+	return vErrs
+}
+
+func (r *GlobalVolumes) merge(with GlobalVolumes) {
+	if *r == nil {
+		*r = GlobalVolumes{}
+	}
+	for path, v := range with {
+		if existing, ok := (*r)[path]; ok {
+			existing.merge(v)
+			(*r)[path] = existing
+		} else {
+			(*r)[path] = v
+		}
+	}
+}
+
+// Sorted returns the global volumes sorted by path
+func (r GlobalVolumes) Sorted() []GlobalVolume {
+	paths := make([]string, 0, len(r))
+	for path := range r {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	res := make([]GlobalVolume, 0, len(r))
+	for _, path := range paths {
+		res = append(res, r[path])
+	}
+	return res
+}
+
+func (r VolumeContent) DescType() string {
+	return "VolumeContent"
+}
+
+func (r VolumeContent) DescName() string {
+	return r.volume + ":" + r.Path
+}
+
+// Volume returns the path of the volume receiving the content
+func (r VolumeContent) Volume() string {
+	return r.volume
+}
+
+func (r VolumeContent) ComponentId() string {
+	return r.cRef.ComponentId()
+}
+
+func (r VolumeContent) Component(model interface{}) (componentizer.Component, error) {
+	return r.cRef.Component(model)
+}
+
+func (r VolumeContent) validate(e Environment, loc DescriptorLocation) ValidationErrors {
+	vErrs := validate(e, loc, r.cRef)
+	if r.Path == "" {
+		vErrs.addError(errors.New("empty content path"), loc.appendPath("path"))
+	}
+	return vErrs
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseVolumesEnvironment(t *testing.T) Environment {
+	yamlEnv := yamlEnvironment{}
+	e := parseYaml("./testdata/yaml/volumes.yaml", &TemplateContext{}, &yamlEnv)
+	assert.Nil(t, e)
+	env, e := CreateEnvironment(component{Id: MainComponentId}, yamlEnv)
+	assert.Nil(t, e)
+	return env
+}
+
+func TestCreateNodeSetVolumes(t *testing.T) {
+	env := parseVolumesEnvironment(t)
+
+	managers := env.NodeSets["managers"]
+	if assert.Len(t, managers.Volumes, 2) {
+		assert.Equal(t, "/var/lib/shared", managers.Volumes[0].Path)
+		assert.Equal(t, 20, managers.Volumes[0].Parameters()["size"])
+		assert.Equal(t, "gp2", managers.Volumes[0].Parameters()["type"])
+		assert.Equal(t, "/var/lib/managers", managers.Volumes[1].Path)
+	}
+
+	workers := env.NodeSets["workers"]
+	assert.Equal(t, []string{"/var/lib/shared", "/var/lib/workers", "/var/lib/workers"}, workers.Volumes.Paths())
+	assert.True(t, workers.Volumes.Contains("/var/lib/shared"))
+	assert.False(t, workers.Volumes.Contains("/var/lib/managers"))
+
+	params := managers.Volumes.AsParams()
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "/var/lib/shared", params[0]["path"])
+		assert.Equal(t, 20, params[0]["params"].(map[string]interface{})["size"])
+	}
+}
+
+func TestCreateGlobalVolumes(t *testing.T) {
+	env := parseVolumesEnvironment(t)
+
+	sorted := env.Volumes.Sorted()
+	if assert.Len(t, sorted, 2) {
+		assert.Equal(t, "/var/lib/nowhere", sorted[0].Path)
+		assert.Equal(t, "/var/lib/shared", sorted[1].Path)
+		if assert.Len(t, sorted[1].Content, 2) {
+			assert.Equal(t, "data", sorted[1].Content[0].ComponentId())
+			assert.Equal(t, "shared", sorted[1].Content[0].Path)
+			assert.Equal(t, "/var/lib/shared", sorted[1].Content[0].Volume())
+		}
+	}
+
+	c, err := sorted[1].Content[0].Component(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "data", c.ComponentId())
+	assert.True(t, env.IsReferenced(c))
+}
+
+func TestMergeVolumes(t *testing.T) {
+	env := parseVolumesEnvironment(t)
+	other := Environment{
+		NodeSets: NodeSets{
+			"managers": NodeSet{
+				Name:      "managers",
+				Instances: 1,
+				Volumes: Volumes{
+					{Path: "/var/lib/managers", params: CreateParameters(map[string]interface{}{"size": 5})},
+					{Path: "/var/lib/logs"},
+				},
+			},
+		},
+		Volumes: GlobalVolumes{
+			"/var/lib/shared": GlobalVolume{
+				Path: "/var/lib/shared",
+				Content: []VolumeContent{
+					{cRef: componentRef{ref: "data"}, Path: "shared", volume: "/var/lib/shared"},
+					{cRef: componentRef{ref: "data"}, Path: "more", volume: "/var/lib/shared"},
+				},
+			},
+			"/var/lib/logs": GlobalVolume{Path: "/var/lib/logs"},
+		},
+	}
+
+	merged, err := env.Merge(other)
+	assert.Nil(t, err)
+	mEnv := merged.(Environment)
+
+	managers := mEnv.NodeSets["managers"]
+	assert.Equal(t, []string{"/var/lib/shared", "/var/lib/managers", "/var/lib/logs"}, managers.Volumes.Paths())
+	assert.Equal(t, 5, managers.Volumes[1].Parameters()["size"])
+
+	assert.Len(t, mEnv.Volumes, 3)
+	assert.Len(t, mEnv.Volumes["/var/lib/shared"].Content, 3)
+}
+
+func TestValidationVolumes(t *testing.T) {
+	env := parseVolumesEnvironment(t)
+	vErrs := env.Validate()
+	assert.True(t, vErrs.HasErrors())
+	assert.True(t, vErrs.contains(Error, "duplicate volume path: /var/lib/workers", "nodes.workers.volumes[2].path"))
+	assert.True(t, vErrs.contains(Error, "no such component: unknown", "volumes./var/lib/shared.content[1].component"))
+	assert.True(t, vErrs.contains(Error, "empty content path", "volumes./var/lib/nowhere.content[0].path"))
+	assert.True(t, vErrs.contains(Warning, "no node set mounts the volume: /var/lib/nowhere", "volumes./var/lib/nowhere"))
+}
+
+func TestValidationNoVolumePath(t *testing.T) {
+	vErrs, _ := testEmptyContent(t, "volume_name", false)
+	assert.True(t, vErrs.contains(Error, "empty volume path", "nodes.managers.volumes[0].path"))
+}
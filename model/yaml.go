@@ -56,6 +56,58 @@ type (
 		Playbooks map[string]string `yaml:",omitempty"`
 	}
 
+	// yaml tag for the options used to launch a playbook
+	yamlPlayOptions struct {
+		// The hosts targeted by the playbook
+		Limit string `yaml:",omitempty"`
+		// The tags of the tasks to run
+		Tags []string `yaml:",omitempty"`
+		// The tags of the tasks to skip
+		SkipTags []string `yaml:"skip_tags,omitempty"`
+		// The number of hosts processed in parallel
+		Forks int `yaml:",omitempty"`
+		// The name of the task where to start the playbook
+		StartAtTask string `yaml:"start_at_task,omitempty"`
+	}
+
+	// yaml tag for the python virtualenv of a component
+	yamlVirtualenv struct {
+		// The name of the virtualenv
+		Name string `yaml:",omitempty"`
+		// The requirements file describing the virtualenv
+		Requirements string `yaml:",omitempty"`
+	}
+
+	// yaml tag for a bastion
+	yamlBastion struct {
+		// The address of the bastion
+		Host string `yaml:",omitempty"`
+		// The user connecting the bastion
+		User string `yaml:",omitempty"`
+		// The path of the private key connecting the bastion
+		Key string `yaml:",omitempty"`
+		// The SSH port of the bastion
+		Port int `yaml:",omitempty"`
+		// The StrictHostKeyChecking mode connecting the bastion
+		HostKeyChecking string `yaml:"host_key_checking,omitempty"`
+	}
+
+	// yaml tag for an existing host of a node set
+	yamlStaticHost struct {
+		// The name of the host, its address by default
+		Name string `yaml:",omitempty"`
+		// The address used to connect the host
+		Address string
+		// The user used to connect the host
+		User string `yaml:",omitempty"`
+		// The SSH port used to connect the host
+		Port int `yaml:",omitempty"`
+		// The labels of the host
+		yamlLabel `yaml:",inline"`
+		// The ansible variables of the host
+		yamlVars `yaml:",inline"`
+	}
+
 	// yaml tag for component
 	yamlComponent struct {
 		// The source repository where the component lives
@@ -132,6 +184,8 @@ type (
 		Templates []string
 		// The list of custom playbooks
 		yamlPlaybooks `yaml:",inline"`
+		// The python virtualenv used to run ansible
+		Virtualenv yamlVirtualenv `yaml:",omitempty"`
 	}
 
 	yamlNode struct {
@@ -151,6 +205,12 @@ type (
 
 		// The labels associated with the nodeset
 		yamlLabel `yaml:",inline"`
+
+		// The existing hosts of the node set, for the static provider
+		Hosts []yamlStaticHost `yaml:",omitempty"`
+
+		// The bastion through which the hosts of the node set are connected
+		Bastion yamlBastion `yaml:",omitempty"`
 	}
 
 	// Definition of the Ekara environment
@@ -169,6 +229,12 @@ type (
 		// The descriptor variables
 		yamlVars `yaml:",inline"`
 
+		// The bastion through which all the hosts are connected
+		Bastion yamlBastion `yaml:",omitempty"`
+
+		// The settings of the ansible.cfg file, by section
+		Ansible map[string]map[string]interface{} `yaml:",omitempty"`
+
 		// Tasks which can be run on the created environment
 		Tasks map[string]struct {
 			// Name of the task component
@@ -179,6 +245,8 @@ type (
 			yamlEnv `yaml:",inline"`
 			// The name of the playbook to launch the task
 			Playbook string `yaml:",omitempty"`
+			// The options used to launch the playbook
+			Options yamlPlayOptions `yaml:",omitempty"`
 			// The Hooks to be executed in addition the the main task playbook
 			Hooks struct {
 				Execute yamlHook `yaml:",omitempty"`
@@ -197,6 +265,8 @@ type (
 
 		// The list of all cloud providers required to create the environment
 		Providers map[string]struct {
+			// Kind of the provider, unset for the providers held by a component
+			Kind string `yaml:",omitempty"`
 			// Name of the provider component
 			Component string
 			// The provider parameters
@@ -205,6 +275,8 @@ type (
 			yamlEnv `yaml:",inline"`
 			// The provider proxy
 			Proxy yamlProxy
+			// The bastion through which the hosts of the provider are connected
+			Bastion yamlBastion `yaml:",omitempty"`
 		}
 
 		// The list of node sets to create
@@ -231,6 +303,8 @@ type (
 
 			// Custom playbook
 			Playbook string
+			// The options used to launch the playbooks
+			Options yamlPlayOptions `yaml:",omitempty"`
 		}
 
 		// Global hooks
@@ -278,6 +352,14 @@ type (
 
 // parseYaml parses the url content, template it and unmarshal it into the out struct.
 func parseYaml(path string, tplC *TemplateContext, out interface{}) error {
+	return parseNamedYaml(fmt.Sprintf("%s:%s", tplC.Component.Type, tplC.Component.Name), path, tplC, out)
+}
+
+// parseNamedYaml parses the url content, template it under the given template
+// name and unmarshal it into the out struct.
+//
+// Templating errors are returned as ValidationErrors located into the descriptor.
+func parseNamedYaml(name string, path string, tplC *TemplateContext, out interface{}) error {
 	// Read descriptor content
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -285,15 +367,23 @@ func parseYaml(path string, tplC *TemplateContext, out interface{}) error {
 	}
 
 	// Parse just the "vars:" section of the descriptor and fill the template context with it
-	err = parseVars(content, tplC)
+	err = parseVars(name, content, tplC)
 	if err != nil {
+		if tErr, ok := err.(TemplateError); ok {
+			return templateValidationErrors(path, tErr)
+		}
 		err = fmt.Errorf("yaml error in %s: %s", path, err.Error())
 		return err
 	}
 
-	// Template the content of the environment descriptor with the updated template context
-	templated, err := tplC.Execute(string(content))
+	// Template the content of the environment descriptor with the updated template context,
+	// leniently as the descriptor may refer to the variables of components not fetched yet
+	tplC.addDescriptor(name, path)
+	templated, err := tplC.execute(name, string(content), false)
 	if err != nil {
+		if tErr, ok := err.(TemplateError); ok {
+			return templateValidationErrors(path, tErr)
+		}
 		return err
 	}
 
@@ -307,8 +397,16 @@ func parseYaml(path string, tplC *TemplateContext, out interface{}) error {
 	return nil
 }
 
+// templateValidationErrors converts a templating error into a validation error
+// located into the given descriptor
+func templateValidationErrors(path string, tErr TemplateError) ValidationErrors {
+	vErrs := ValidationErrors{}
+	vErrs.addError(tErr, DescriptorLocation{Descriptor: path})
+	return vErrs
+}
+
 // parseVars parses the "vars:" section of the descriptor
-func parseVars(content []byte, tplC *TemplateContext) error {
+func parseVars(name string, content []byte, tplC *TemplateContext) error {
 	readVars := func(b []byte) (yamlVars, error) {
 		yVars := yamlVars{}
 		err := yaml.Unmarshal(b, &yVars)
@@ -331,7 +429,7 @@ func parseVars(content []byte, tplC *TemplateContext) error {
 	}
 
 	// Apply template
-	templatedVars, err := tplC.Execute(string(onlyVars))
+	templatedVars, err := tplC.execute(name, string(onlyVars), false)
 	if err != nil {
 		return err
 	}
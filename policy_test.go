@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ekara-platform/engine/action"
+	"github.com/ekara-platform/engine/model"
+	"github.com/ekara-platform/engine/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// policyLaunchContext uses the given policy rules file
+type policyLaunchContext struct {
+	util.LaunchContext
+	rules string
+}
+
+func (lC policyLaunchContext) PolicyRulesFile() string {
+	return lC.rules
+}
+
+// registeredLaunchContext registers the given policy rules
+type registeredLaunchContext struct {
+	util.LaunchContext
+	rules []model.PolicyRule
+}
+
+func (lC registeredLaunchContext) PolicyRules() []model.PolicyRule {
+	return lC.rules
+}
+
+func TestPolicyRulesFile(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", "name: policy\n")
+	tester.Init(repDesc.AsRepository("master"))
+
+	f, err := ioutil.TempFile("", "rules")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("rules:\n  - id: qualified\n    path: qualifier\n    operator: exists\n")
+	assert.Nil(t, err)
+	f.Close()
+
+	eng := &engine{
+		lC:          policyLaunchContext{util.CreateMockLaunchContext(false), f.Name()},
+		environment: tester.Env(),
+	}
+	env, err := eng.policyEnvironment()
+	assert.Nil(t, err)
+	vErrs := env.Validate()
+	assert.True(t, vErrs.HasErrors())
+	assert.Contains(t, vErrs.Error(), "Error[qualified]")
+
+	// The rules are loaded again by each execution, without altering the engine environment
+	assert.Len(t, eng.environment.PolicyRules(), 0)
+	env, err = eng.policyEnvironment()
+	assert.Nil(t, err)
+	assert.Len(t, env.PolicyRules(), 1)
+
+	// A missing file prevents the execution
+	eng.lC = policyLaunchContext{util.CreateMockLaunchContext(false), f.Name() + ".missing"}
+	_, err = eng.policyEnvironment()
+	assert.NotNil(t, err)
+}
+
+func TestPolicyRulesRegistered(t *testing.T) {
+	tester := util.CreateComponentTester(t, model.CreateEmptyParameters())
+	defer tester.Clean()
+	repDesc := tester.CreateDir("descriptor")
+	repDesc.WriteCommit("ekara.yaml", "name: policy\n")
+	tester.Init(repDesc.AsRepository("master"))
+
+	f, err := ioutil.TempFile("", "rules")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("rules:\n  - id: qualified\n    path: qualifier\n    operator: exists\n    severity: warning\n")
+	assert.Nil(t, err)
+	f.Close()
+
+	named := model.PolicyRule{
+		Id:       "named-dev",
+		Severity: model.Error,
+		Check: func(env model.Environment) []model.PolicyViolation {
+			if env.QName.Name == "policy" {
+				return []model.PolicyViolation{{Path: "name", Message: "the name is reserved"}}
+			}
+			return nil
+		},
+	}
+	lC := registeredLaunchContext{policyLaunchContext{util.CreateMockLaunchContext(false), f.Name()}, []model.PolicyRule{named}}
+	eng := &engine{
+		lC:               lC,
+		environment:      tester.Env(),
+		componentManager: tester.ComponentManager(),
+		tplC:             tester.TemplateContext().(*model.TemplateContext),
+	}
+
+	// The rules registered as Go functions are evaluated by the check, along
+	// with the ones of the rules file
+	env, err := eng.policyEnvironment()
+	assert.Nil(t, err)
+	var check action.Action
+	for _, a := range action.All() {
+		if a.Id == action.CheckActionID {
+			check = a
+		}
+	}
+	report, _ := check.Execute(action.CreateRuntimeContext(lC, eng.componentManager, nil, env, eng.tplC))
+	assert.NotNil(t, report.Error)
+	if assert.Len(t, report.Steps.Status, 1) {
+		vErrs := model.ValidationErrors{}
+		assert.Nil(t, json.Unmarshal([]byte(report.Steps.Status[0].RawContent.(string)), &vErrs))
+		rules := make(map[string]model.ErrorType)
+		for _, vErr := range vErrs.Errors {
+			if vErr.Rule != "" {
+				rules[vErr.Rule] = vErr.ErrorType
+			}
+		}
+		assert.Equal(t, map[string]model.ErrorType{"named-dev": model.Error, "qualified": model.Warning}, rules)
+	}
+}
@@ -0,0 +1,148 @@
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+//EncryptFile encrypts in place all the plain string values of a yaml secrets
+//file, the values already encrypted are left untouched.
+func EncryptFile(path string, k *Key) error {
+	return transformFile(path, func(v string) (string, error) {
+		if IsEncrypted(v) {
+			return v, nil
+		}
+		return Encrypt(k, v)
+	})
+}
+
+//DecryptFile decrypts in place all the encrypted values of a yaml secrets file
+func DecryptFile(path string, k *Key) error {
+	return transformFile(path, func(v string) (string, error) {
+		if !IsEncrypted(v) {
+			return v, nil
+		}
+		return Decrypt(k, v)
+	})
+}
+
+//RotateFile re-encrypts in place all the values of a yaml secrets file
+//with a new key.
+//
+//The plain string values are encrypted as well.
+func RotateFile(path string, oldKey *Key, newKey *Key) error {
+	return transformFile(path, func(v string) (string, error) {
+		if IsEncrypted(v) {
+			d, err := Decrypt(oldKey, v)
+			if err != nil {
+				return "", err
+			}
+			v = d
+		}
+		return Encrypt(newKey, v)
+	})
+}
+
+//ReadFile returns the content of a yaml secrets file with all its encrypted
+//values decrypted.
+//
+//The decrypted values are marked as sensitive.
+func ReadFile(path string, k *Key) (map[string]interface{}, error) {
+	content, err := readYaml(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := transform(content, func(v string) (string, error) {
+		if !IsEncrypted(v) {
+			return v, nil
+		}
+		d, err := Decrypt(k, v)
+		if err != nil {
+			return "", err
+		}
+		MarkSensitive(d)
+		return d, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading secrets file %s: %s", path, err.Error())
+	}
+	return res.(map[string]interface{}), nil
+}
+
+func readYaml(path string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := make(map[string]interface{})
+	err = yaml.Unmarshal(b, &content)
+	if err != nil {
+		return nil, fmt.Errorf("yaml error in %s : %s", path, err.Error())
+	}
+	return content, nil
+}
+
+// transformFile applies the given function to all the string values of a
+// yaml file and writes back the result
+func transformFile(path string, f func(string) (string, error)) error {
+	content, err := readYaml(path)
+	if err != nil {
+		return err
+	}
+	res, err := transform(content, f)
+	if err != nil {
+		return fmt.Errorf("error processing secrets file %s: %s", path, err.Error())
+	}
+	b, err := yaml.Marshal(res)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, info.Mode())
+}
+
+// transform applies recursively the given function to all the string values
+func transform(v interface{}, f func(string) (string, error)) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return f(t)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			tv, err := transform(val, f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", k, err.Error())
+			}
+			res[k] = tv
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			ks := fmt.Sprintf("%v", k)
+			tv, err := transform(val, f)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", ks, err.Error())
+			}
+			res[ks] = tv
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, val := range t {
+			tv, err := transform(val, f)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %s", i, err.Error())
+			}
+			res[i] = tv
+		}
+		return res, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,158 @@
+package secret
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	//DefaultSensitiveKeyPatterns are the patterns identifying, by default,
+	//the keys holding sensitive values.
+	//
+	//They match whole segments of the keys, to not catch keys like "tokenizer",
+	//and the keys ending with "key", like "api_key" or "ssh_private_key". The
+	//public keys are explicitly excluded, as well as the keys only referring
+	//to a key, like "key_file".
+	DefaultSensitiveKeyPatterns = []string{
+		"(^|_)(password|passwd|token|secret)s?($|_)",
+		"(^|_)keys?$",
+		"!(^|_)public_keys?$",
+	}
+
+	sensitiveKeyPatterns   = compilePatterns(DefaultSensitiveKeyPatterns)
+	sensitiveKeyPatternsMu sync.RWMutex
+)
+
+// keyPattern is a compiled sensitive key pattern
+type keyPattern struct {
+	re      *regexp.Regexp
+	exclude bool
+}
+
+//SetSensitiveKeyPatterns replaces the patterns identifying the keys holding
+//sensitive values.
+//
+//Patterns are case insensitive regular expressions matched against the keys
+//converted into snake case, like "ssh_private_key" for "SSHPrivateKey". A
+//pattern starting with "!" excludes the matching keys, whatever the other
+//patterns.
+func SetSensitiveKeyPatterns(patterns ...string) error {
+	for _, p := range patterns {
+		if _, err := regexp.Compile("(?i)" + strings.TrimPrefix(p, "!")); err != nil {
+			return fmt.Errorf("invalid sensitive key pattern %s: %s", p, err.Error())
+		}
+	}
+	sensitiveKeyPatternsMu.Lock()
+	defer sensitiveKeyPatternsMu.Unlock()
+	sensitiveKeyPatterns = compilePatterns(patterns)
+	return nil
+}
+
+//IsSensitiveKey returns true if the key matches one of the sensitive key patterns
+func IsSensitiveKey(key string) bool {
+	key = snakeCase(key)
+	sensitiveKeyPatternsMu.RLock()
+	defer sensitiveKeyPatternsMu.RUnlock()
+	res := false
+	for _, p := range sensitiveKeyPatterns {
+		if p.re.MatchString(key) {
+			if p.exclude {
+				return false
+			}
+			res = true
+		}
+	}
+	return res
+}
+
+//MarkSensitiveContent walks the given content and marks as sensitive:
+//  - all the string values held by a sensitive key
+//  - the passwords of the URLs
+//
+//Once marked the values are hidden from everything going through Hide.
+func MarkSensitiveContent(v interface{}) {
+	markContent(reflect.ValueOf(v), false)
+}
+
+//MarkSensitiveValues marks as sensitive all the string values of the given content
+func MarkSensitiveValues(v interface{}) {
+	markContent(reflect.ValueOf(v), true)
+}
+
+func markContent(rv reflect.Value, sensitive bool) {
+	if !rv.IsValid() {
+		return
+	}
+	switch rv.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !rv.IsNil() {
+			markContent(rv.Elem(), sensitive)
+		}
+	case reflect.Map:
+		for _, k := range rv.MapKeys() {
+			markContent(rv.MapIndex(k), sensitive || IsSensitiveKey(fmt.Sprintf("%v", k.Interface())))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			markContent(rv.Index(i), sensitive)
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			if rv.Type().Field(i).PkgPath != "" {
+				// Unexported field
+				continue
+			}
+			markContent(rv.Field(i), sensitive || IsSensitiveKey(rv.Type().Field(i).Name))
+		}
+	case reflect.String:
+		s := rv.String()
+		if sensitive {
+			MarkSensitive(s)
+		} else if strings.Contains(s, "@") {
+			if u, err := url.Parse(s); err == nil && u.User != nil {
+				if p, ok := u.User.Password(); ok {
+					MarkSensitive(p)
+				}
+			}
+		}
+	}
+}
+
+// snakeCase converts a key into lower snake case, splitting the camel case
+// words and replacing the other separators by underscores
+func snakeCase(key string) string {
+	runes := []rune(key)
+	sb := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteRune('_')
+			}
+		}
+		switch r {
+		case '-', '.', ' ':
+			sb.WriteRune('_')
+		default:
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+func compilePatterns(patterns []string) []keyPattern {
+	res := make([]keyPattern, 0, len(patterns))
+	for _, p := range patterns {
+		res = append(res, keyPattern{
+			re:      regexp.MustCompile("(?i)" + strings.TrimPrefix(p, "!")),
+			exclude: strings.HasPrefix(p, "!"),
+		})
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 24
+
+	encryptedPrefix = "ENC[secretbox,"
+	encryptedSuffix = "]"
+)
+
+//Key is the local symmetric key used to encrypt and decrypt secrets
+type Key [keySize]byte
+
+//GenerateKey creates a new random key
+func GenerateKey() (*Key, error) {
+	k := new(Key)
+	if _, err := io.ReadFull(rand.Reader, k[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+//ParseKey decodes a base64 encoded key
+func ParseKey(s string) (*Key, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %s", err.Error())
+	}
+	if len(b) != keySize {
+		return nil, fmt.Errorf("invalid secret key: expected %d bytes, got %d", keySize, len(b))
+	}
+	k := new(Key)
+	copy(k[:], b)
+	return k, nil
+}
+
+//LoadKey reads a base64 encoded key from a file
+func LoadKey(path string) (*Key, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(string(b))
+}
+
+//Save writes the base64 encoded key into a file only readable by its owner
+func (k *Key) Save(path string) error {
+	return ioutil.WriteFile(path, []byte(k.String()+"\n"), 0600)
+}
+
+//String returns the base64 encoded key
+func (k *Key) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+//IsEncrypted returns true if the value has been produced by Encrypt
+func IsEncrypted(value string) bool {
+	v := strings.TrimSpace(value)
+	return strings.HasPrefix(v, encryptedPrefix) && strings.HasSuffix(v, encryptedSuffix)
+}
+
+//Encrypt encrypts a value with the given key.
+//
+//The result has the form "ENC[secretbox,<base64 content>]" and can be
+//used as is into a descriptor or a vars file.
+func Encrypt(k *Key, value string) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", err
+	}
+	sealed := secretbox.Seal(nonce[:], []byte(value), &nonce, (*[keySize]byte)(k))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+//Decrypt decrypts a value produced by Encrypt with the given key
+func Decrypt(k *Key, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("the value is not an encrypted secret")
+	}
+	v := strings.TrimSpace(value)
+	b, err := base64.StdEncoding.DecodeString(v[len(encryptedPrefix) : len(v)-len(encryptedSuffix)])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %s", err.Error())
+	}
+	if len(b) < nonceSize+secretbox.Overhead {
+		return "", fmt.Errorf("invalid encrypted secret: content too short")
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], b[:nonceSize])
+	opened, ok := secretbox.Open(nil, b[nonceSize:], &nonce, (*[keySize]byte)(k))
+	if !ok {
+		return "", fmt.Errorf("unable to decrypt the secret, the key does not match")
+	}
+	return string(opened), nil
+}
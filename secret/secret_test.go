@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, err := GenerateKey()
+	assert.Nil(t, err)
+
+	enc, err := Encrypt(k, "my password")
+	assert.Nil(t, err)
+	assert.True(t, IsEncrypted(enc))
+	assert.NotContains(t, enc, "my password")
+
+	dec, err := Decrypt(k, enc)
+	assert.Nil(t, err)
+	assert.Equal(t, "my password", dec)
+
+	other, err := GenerateKey()
+	assert.Nil(t, err)
+	_, err = Decrypt(other, enc)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to decrypt the secret, the key does not match", err.Error())
+	}
+
+	_, err = Decrypt(k, "my password")
+	assert.NotNil(t, err)
+}
+
+func TestSaveLoadKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ekara_secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	k, err := GenerateKey()
+	assert.Nil(t, err)
+	path := filepath.Join(dir, "secret.key")
+	assert.Nil(t, k.Save(path))
+
+	loaded, err := LoadKey(path)
+	assert.Nil(t, err)
+	assert.Equal(t, *k, *loaded)
+
+	_, err = ParseKey("dG9vIHNob3J0")
+	assert.NotNil(t, err)
+}
+
+func TestSecretsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ekara_secret")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+aws:
+  access_key: access
+  secret_key: secret
+tokens:
+  - token1
+port: 22
+`), 0600)
+	assert.Nil(t, err)
+
+	k1, _ := GenerateKey()
+	k2, _ := GenerateKey()
+
+	// Encrypt
+	assert.Nil(t, EncryptFile(path, k1))
+	b, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "access_key: access")
+	assert.NotContains(t, string(b), "token1")
+	assert.Contains(t, string(b), "port: 22")
+
+	content, err := ReadFile(path, k1)
+	assert.Nil(t, err)
+	assert.Equal(t, "access", content["aws"].(map[string]interface{})["access_key"])
+	assert.Equal(t, "token1", content["tokens"].([]interface{})[0])
+	assert.True(t, IsSensitive("secret"))
+
+	// Rotate
+	assert.Nil(t, RotateFile(path, k1, k2))
+	_, err = ReadFile(path, k1)
+	assert.NotNil(t, err)
+	content, err = ReadFile(path, k2)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", content["aws"].(map[string]interface{})["secret_key"])
+
+	// Decrypt
+	assert.Nil(t, DecryptFile(path, k2))
+	b, err = ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "access_key: access")
+}
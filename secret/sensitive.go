@@ -0,0 +1,130 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	//Mask is the replacement of the sensitive values
+	Mask = "******"
+
+	//MinHiddenLength is the minimal length of the sensitive values hidden
+	//within a text, the shorter ones are only hidden when they are the whole
+	//value, to not mask unrelated content
+	MinHiddenLength = 6
+)
+
+var (
+	sensitiveValues   = make(map[string]struct{})
+	sensitiveValuesMu sync.RWMutex
+)
+
+//MarkSensitive registers values which must never be disclosed
+func MarkSensitive(values ...string) {
+	sensitiveValuesMu.Lock()
+	defer sensitiveValuesMu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			sensitiveValues[v] = struct{}{}
+		}
+	}
+}
+
+//IsSensitive returns true if the value has been marked as sensitive
+func IsSensitive(value string) bool {
+	sensitiveValuesMu.RLock()
+	defer sensitiveValuesMu.RUnlock()
+	_, ok := sensitiveValues[value]
+	return ok
+}
+
+//Hide replaces all the sensitive values contained into the given string by Mask.
+//
+//The sensitive values shorter than MinHiddenLength are only replaced when they
+//are the whole string.
+func Hide(s string) string {
+	if IsSensitive(s) {
+		return Mask
+	}
+	sensitiveValuesMu.RLock()
+	values := make([]string, 0, len(sensitiveValues))
+	for v := range sensitiveValues {
+		if len(v) >= MinHiddenLength {
+			values = append(values, v)
+		}
+	}
+	sensitiveValuesMu.RUnlock()
+
+	// Longest values first, in case of values containing others
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	for _, v := range values {
+		s = strings.Replace(s, v, Mask, -1)
+	}
+	return s
+}
+
+//HideContent returns a copy of the given content, made of maps, slices and
+//scalars like an unmarshalled document, where the strings held by a sensitive
+//key are replaced by Mask and the other ones are hidden by Hide
+func HideContent(v interface{}) interface{} {
+	return hideContent(v, false)
+}
+
+func hideContent(v interface{}, sensitive bool) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(c))
+		for k, val := range c {
+			res[k] = hideContent(val, sensitive || IsSensitiveKey(k))
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(c))
+		for k, val := range c {
+			res[k] = hideContent(val, sensitive || IsSensitiveKey(fmt.Sprintf("%v", k)))
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(c))
+		for i, val := range c {
+			res[i] = hideContent(val, sensitive)
+		}
+		return res
+	case string:
+		if sensitive && c != "" {
+			return Mask
+		}
+		return Hide(c)
+	}
+	return v
+}
+
+//HidingWriter returns a writer hiding the sensitive values before writing
+//into the given one.
+//
+//Each write is processed independently, sensitive values split across
+//several writes are not detected.
+func HidingWriter(w io.Writer) io.Writer {
+	return hidingWriter{w: w}
+}
+
+type hidingWriter struct {
+	w io.Writer
+}
+
+func (h hidingWriter) Write(p []byte) (int, error) {
+	_, err := h.w.Write([]byte(Hide(string(p))))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
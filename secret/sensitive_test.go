@@ -0,0 +1,49 @@
+package secret
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHide(t *testing.T) {
+	MarkSensitive("abc", "xyzabc123", "")
+	assert.Equal(t, "user: admin, password: ******", Hide("user: admin, password: xyzabc123"))
+	assert.True(t, IsSensitive("abc"))
+	assert.False(t, IsSensitive(""))
+
+	// The short values are only hidden when they are the whole value
+	assert.Equal(t, "abc ******", Hide("abc xyzabc123"))
+	assert.Equal(t, Mask, Hide("abc"))
+}
+
+func TestHideContent(t *testing.T) {
+	MarkSensitive("22", "hidden-value")
+	content := map[string]interface{}{
+		"port":        "22",
+		"description": "port 22 of hidden-value",
+		"api_token":   "any",
+		"hosts":       []interface{}{"web", map[interface{}]interface{}{"secret": []interface{}{"nested"}}},
+		"instances":   22,
+	}
+	assert.Equal(t, map[string]interface{}{
+		"port":        Mask,
+		"description": "port 22 of " + Mask,
+		"api_token":   Mask,
+		"hosts":       []interface{}{"web", map[interface{}]interface{}{"secret": []interface{}{Mask}}},
+		"instances":   22,
+	}, HideContent(content))
+
+	// The content itself is left untouched
+	assert.Equal(t, "22", content["port"])
+}
+
+func TestHidingWriter(t *testing.T) {
+	MarkSensitive("s3cr3t")
+	var b bytes.Buffer
+	l := log.New(HidingWriter(&b), "", 0)
+	l.Printf("the token is %s", "s3cr3t")
+	assert.Equal(t, "the token is ******\n", b.String())
+}
@@ -0,0 +1,300 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	//DefaultPort is the SSH port used unless specified
+	DefaultPort = 22
+	//DefaultTimeout is the maximum duration to establish a connection unless specified
+	DefaultTimeout = 10 * time.Second
+	//DefaultConcurrency is the number of hosts processed in parallel unless specified
+	DefaultConcurrency = 20
+)
+
+type (
+	//Target is a host to connect through SSH
+	Target struct {
+		// Name identifies the host
+		Name string
+		// Address is the address of the host, its name if empty
+		Address string
+		// Port is the SSH port of the host, DefaultPort if zero
+		Port int
+		// User is the user connecting the host, the default one of the options if empty
+		User string
+		// Jump is the bastion through which the host is connected, if any
+		Jump *Jump
+	}
+
+	//Jump is a bastion through which a target is connected
+	Jump struct {
+		Target
+		// Signer authenticates the connection to the bastion, the one of the options if nil
+		Signer ssh.Signer
+	}
+
+	//ConnectOptions specifies how to connect the hosts
+	ConnectOptions struct {
+		// Signer authenticates the connections
+		Signer ssh.Signer
+		// User is the user connecting the hosts which don't specify one
+		User string
+		// Timeout is the maximum duration to establish a connection, DefaultTimeout if zero
+		Timeout time.Duration
+		// HostKeyCallback checks the host keys, the known hosts file is used if nil
+		HostKeyCallback ssh.HostKeyCallback
+		// KnownHostsFile lists the accepted host keys, DefaultKnownHostsFile if empty
+		KnownHostsFile string
+		// InsecureIgnoreHostKey accepts any host key, when no callback is specified
+		InsecureIgnoreHostKey bool
+	}
+
+	//CheckResult is the connectivity of a host
+	CheckResult struct {
+		// Host is the name of the host
+		Host string
+		// Address is the address and port connected
+		Address string
+		// Reachable tells if the SSH port of the host is reachable
+		Reachable bool
+		// AuthOK tells if the authentication succeeded
+		AuthOK bool
+		// Latency is the duration to open the TCP connection
+		Latency time.Duration
+		// HostKeyFingerprint is the SHA256 fingerprint of the key of the host
+		HostKeyFingerprint string `json:",omitempty"`
+		// Error explains why the host cannot be used
+		Error string `json:",omitempty"`
+	}
+)
+
+//HostPort returns the address and port to connect
+func (t Target) HostPort() string {
+	addr := t.Address
+	if addr == "" {
+		addr = t.Name
+	}
+	port := t.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	return net.JoinHostPort(addr, strconv.Itoa(port))
+}
+
+func (o ConnectOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return DefaultTimeout
+}
+
+//DefaultKnownHostsFile returns the known hosts file of the current user
+func DefaultKnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// hostKeyCallback returns the callback checking the host keys, from the known
+// hosts file unless specified otherwise
+func (o ConnectOptions) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if o.HostKeyCallback != nil {
+		return o.HostKeyCallback, nil
+	}
+	if o.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	file := o.KnownHostsFile
+	if file == "" {
+		file = DefaultKnownHostsFile()
+	}
+	check, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the known hosts: %s", err.Error())
+	}
+	return check, nil
+}
+
+// clientConfig returns the configuration connecting the target, recording
+// the fingerprint of its host key
+func (o ConnectOptions) clientConfig(t Target, fingerprint *string) (*ssh.ClientConfig, error) {
+	if o.Signer == nil {
+		return nil, errors.New("no private key to authenticate the connection")
+	}
+	user := t.User
+	if user == "" {
+		user = o.User
+	}
+	check, err := o.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(o.Signer)},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			*fingerprint = ssh.FingerprintSHA256(key)
+			return check(hostname, remote, key)
+		},
+		Timeout: o.timeout(),
+	}, nil
+}
+
+//Connect opens an authenticated connection to the target
+func Connect(t Target, o ConnectOptions) (*ssh.Client, error) {
+	return connect(t, o, &CheckResult{})
+}
+
+// connect opens the connection to the target, recording its progress into the result
+func connect(t Target, o ConnectOptions, res *CheckResult) (*ssh.Client, error) {
+	res.Host = t.Name
+	res.Address = t.HostPort()
+	config, err := o.clientConfig(t, &res.HostKeyFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	conn, jump, err := dial(t, o, res.Address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach %s: %s", res.Address, err.Error())
+	}
+	res.Latency = time.Since(start)
+	res.Reachable = true
+
+	// The handshake must not hang on an unresponsive host
+	conn.SetDeadline(time.Now().Add(o.timeout()))
+	c, chans, reqs, err := ssh.NewClientConn(conn, res.Address, config)
+	if err != nil {
+		conn.Close()
+		if jump != nil {
+			jump.Close()
+		}
+		return nil, fmt.Errorf("unable to authenticate on %s: %s", res.Address, err.Error())
+	}
+	conn.SetDeadline(time.Time{})
+	res.AuthOK = true
+	client := ssh.NewClient(c, chans, reqs)
+	if jump != nil {
+		// The bastion connection lives as long as the one it carries
+		go func() {
+			client.Wait()
+			jump.Close()
+		}()
+	}
+	return client, nil
+}
+
+// dial opens the network connection to the target address, through its
+// bastion if any, and returns the connection to the bastion to close along
+func dial(t Target, o ConnectOptions, address string) (net.Conn, *ssh.Client, error) {
+	if t.Jump == nil {
+		conn, err := net.DialTimeout("tcp", address, o.timeout())
+		return conn, nil, err
+	}
+	jo := o
+	if t.Jump.Signer != nil {
+		jo.Signer = t.Jump.Signer
+	}
+	jump, err := connect(t.Jump.Target, jo, &CheckResult{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("through the bastion: %s", err.Error())
+	}
+	conn, err := jump.Dial("tcp", address)
+	if err != nil {
+		jump.Close()
+		return nil, nil, err
+	}
+	return conn, jump, nil
+}
+
+//Run runs the command through the connection and returns its outputs and exit code
+func Run(client *ssh.Client, command string) (stdout string, stderr string, code int, err error) {
+	var outB, errB bytes.Buffer
+	code, err = runWith(client, command, &outB, &errB)
+	return outB.String(), errB.String(), code, err
+}
+
+// runWith runs the command through the connection, writing its outputs into
+// the given writers, and returns its exit code
+func runWith(client *ssh.Client, command string, stdout io.Writer, stderr io.Writer) (int, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	err = session.Run(command)
+	if err != nil {
+		if exit, ok := err.(*ssh.ExitError); ok {
+			return exit.ExitStatus(), nil
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+//Check connects the target and runs a trivial command, to make sure the
+//host can be used
+func Check(t Target, o ConnectOptions) CheckResult {
+	res := CheckResult{}
+	client, err := connect(t, o, &res)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer client.Close()
+	if _, stderr, code, err := Run(client, "true"); err != nil {
+		res.Error = fmt.Sprintf("unable to run a command on %s: %s", res.Address, err.Error())
+	} else if code != 0 {
+		res.Error = fmt.Sprintf("unable to run a command on %s (%d): %s", res.Address, code, stderr)
+	}
+	return res
+}
+
+//CheckAll checks the connectivity of all the targets, processing the given
+//number of targets in parallel, and returns the results in the same order
+func CheckAll(targets []Target, o ConnectOptions, concurrency int) []CheckResult {
+	res := make([]CheckResult, len(targets))
+	parallel(len(targets), concurrency, func(i int) {
+		res[i] = Check(targets[i], o)
+	})
+	return res
+}
+
+// parallel calls f for each index, with at most the given number of concurrent calls
+func parallel(count int, concurrency int, f func(i int)) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,166 @@
+package ssh
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekara-platform/engine/ssh/sshtest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func testSigner(t *testing.T) ssh.Signer {
+	k, err := GenerateKeyPair(KeyOptions{})
+	assert.Nil(t, err)
+	signer, err := k.Signer()
+	assert.Nil(t, err)
+	return signer
+}
+
+func echoHandler(user string, command string) (string, string, int) {
+	if strings.HasPrefix(command, "fail") {
+		return "", "failed", 3
+	}
+	return user + ":" + command, "", 0
+}
+
+// unusedPort returns a local port where nothing listens
+func unusedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+// knownHostsFile writes a known hosts file accepting the given key for the server
+func knownHostsFile(t *testing.T, s *sshtest.Server, key ssh.PublicKey) string {
+	f, err := ioutil.TempFile("", "known_hosts")
+	assert.Nil(t, err)
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(s.HostPort())}, key) + "\n")
+	assert.Nil(t, err)
+	return f.Name()
+}
+
+func TestCheck(t *testing.T) {
+	signer := testSigner(t)
+	s, err := sshtest.NewServer([]ssh.PublicKey{signer.PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+	known := knownHostsFile(t, s, s.HostKey)
+	defer os.Remove(known)
+
+	opts := ConnectOptions{Signer: signer, KnownHostsFile: known, User: "ekara", Timeout: 2 * time.Second}
+	res := Check(Target{Name: "host1", Address: s.Address(), Port: s.Port()}, opts)
+	assert.Equal(t, "host1", res.Host)
+	assert.Equal(t, s.HostPort(), res.Address)
+	assert.True(t, res.Reachable)
+	assert.True(t, res.AuthOK)
+	assert.Equal(t, ssh.FingerprintSHA256(s.HostKey), res.HostKeyFingerprint)
+	assert.Equal(t, "", res.Error)
+}
+
+func TestCheckChangedHostKey(t *testing.T) {
+	signer := testSigner(t)
+	s, err := sshtest.NewServer([]ssh.PublicKey{signer.PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+	known := knownHostsFile(t, s, testSigner(t).PublicKey())
+	defer os.Remove(known)
+
+	// The host presents another key than the known one
+	res := Check(Target{Name: "host1", Address: s.Address(), Port: s.Port()}, ConnectOptions{Signer: signer, KnownHostsFile: known, Timeout: 2 * time.Second})
+	assert.True(t, res.Reachable)
+	assert.False(t, res.AuthOK)
+	assert.Equal(t, ssh.FingerprintSHA256(s.HostKey), res.HostKeyFingerprint)
+	assert.Contains(t, res.Error, "key mismatch")
+
+	// The host is connected only once explicitly accepting any key
+	res = Check(Target{Name: "host1", Address: s.Address(), Port: s.Port()}, ConnectOptions{Signer: signer, KnownHostsFile: known, InsecureIgnoreHostKey: true, Timeout: 2 * time.Second})
+	assert.True(t, res.AuthOK)
+	assert.Equal(t, "", res.Error)
+}
+
+func TestCheckMissingKnownHosts(t *testing.T) {
+	res := Check(Target{Name: "host1", Address: "127.0.0.1", Port: unusedPort(t)}, ConnectOptions{Signer: testSigner(t), KnownHostsFile: "missing_known_hosts"})
+	assert.False(t, res.Reachable)
+	assert.Contains(t, res.Error, "unable to load the known hosts")
+}
+
+func TestCheckUnauthorized(t *testing.T) {
+	s, err := sshtest.NewServer([]ssh.PublicKey{testSigner(t).PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	res := Check(Target{Name: "host1", Address: s.Address(), Port: s.Port()}, ConnectOptions{Signer: testSigner(t), InsecureIgnoreHostKey: true, Timeout: 2 * time.Second})
+	assert.True(t, res.Reachable)
+	assert.False(t, res.AuthOK)
+	assert.Equal(t, ssh.FingerprintSHA256(s.HostKey), res.HostKeyFingerprint)
+	assert.Contains(t, res.Error, "unable to authenticate")
+}
+
+func TestCheckUnreachable(t *testing.T) {
+	res := Check(Target{Name: "host1", Address: "127.0.0.1", Port: unusedPort(t)}, ConnectOptions{Signer: testSigner(t), InsecureIgnoreHostKey: true, Timeout: 2 * time.Second})
+	assert.False(t, res.Reachable)
+	assert.False(t, res.AuthOK)
+	assert.Contains(t, res.Error, "unable to reach")
+}
+
+func TestCheckAll(t *testing.T) {
+	signer := testSigner(t)
+	s, err := sshtest.NewServer([]ssh.PublicKey{signer.PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	targets := []Target{
+		{Name: "host1", Address: s.Address(), Port: s.Port()},
+		{Name: "host2", Address: "127.0.0.1", Port: unusedPort(t)},
+		{Name: "host3", Address: s.Address(), Port: s.Port()},
+	}
+	res := CheckAll(targets, ConnectOptions{Signer: signer, InsecureIgnoreHostKey: true, Timeout: 2 * time.Second}, 2)
+	if assert.Len(t, res, 3) {
+		assert.Equal(t, "host1", res[0].Host)
+		assert.True(t, res[0].AuthOK)
+		assert.Equal(t, "host2", res[1].Host)
+		assert.False(t, res[1].Reachable)
+		assert.Equal(t, "host3", res[2].Host)
+		assert.True(t, res[2].AuthOK)
+	}
+}
+
+func TestCheckThroughBastion(t *testing.T) {
+	signer := testSigner(t)
+	bastionSigner := testSigner(t)
+	s, err := sshtest.NewServer([]ssh.PublicKey{signer.PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+	bastion, err := sshtest.NewServer([]ssh.PublicKey{bastionSigner.PublicKey()}, nil)
+	assert.Nil(t, err)
+	defer bastion.Close()
+
+	opts := ConnectOptions{Signer: signer, InsecureIgnoreHostKey: true, Timeout: 2 * time.Second}
+	jump := &Jump{Target: Target{Name: "bastion", Address: bastion.Address(), Port: bastion.Port()}, Signer: bastionSigner}
+	res := Check(Target{Name: "host1", Address: s.Address(), Port: s.Port(), Jump: jump}, opts)
+	assert.True(t, res.Reachable)
+	assert.True(t, res.AuthOK)
+	assert.Equal(t, ssh.FingerprintSHA256(s.HostKey), res.HostKeyFingerprint)
+	assert.Equal(t, "", res.Error)
+
+	// The bastion rejects the key of the hosts
+	jump.Signer = nil
+	res = Check(Target{Name: "host1", Address: s.Address(), Port: s.Port(), Jump: jump}, opts)
+	assert.False(t, res.Reachable)
+	assert.Contains(t, res.Error, "through the bastion")
+
+	// The host is not reachable from the bastion
+	jump.Signer = bastionSigner
+	res = Check(Target{Name: "host2", Address: "127.0.0.1", Port: unusedPort(t), Jump: jump}, opts)
+	assert.False(t, res.Reachable)
+	assert.Contains(t, res.Error, "unable to reach")
+}
@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	//RunResult is the outcome of a command run on a host
+	RunResult struct {
+		// Host is the name of the host
+		Host string
+		// ExitCode is the exit code of the command, -1 if it didn't complete
+		ExitCode int
+		// Stdout is the standard output of the command
+		Stdout string
+		// Stderr is the error output of the command
+		Stderr string
+		// Duration is the duration of the connection and the command
+		Duration time.Duration
+		// Error explains why the command could not be run
+		Error string `json:",omitempty"`
+	}
+
+	//OutputHandler receives each line written by a command, as soon as it's written
+	OutputHandler func(host string, line string, stderr bool)
+
+	// lineWriter passes the complete lines written to a handler
+	lineWriter struct {
+		host    string
+		stderr  bool
+		handler OutputHandler
+		// mu serializes the calls to the handler, shared by all the writers
+		mu  *sync.Mutex
+		buf bytes.Buffer
+	}
+)
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line until the rest is written
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.notify(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// flush passes the last line, if not terminated by a new line
+func (w *lineWriter) flush() {
+	if w.buf.Len() > 0 {
+		w.notify(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+func (w *lineWriter) notify(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handler(w.host, line, w.stderr)
+}
+
+//RunAll runs the command on all the targets, processing the given number of
+//targets in parallel, and returns the results in the same order.
+//
+//The output handler, if any, receives the output of the command on each
+//host while it runs.
+func RunAll(targets []Target, o ConnectOptions, command string, concurrency int, output OutputHandler) []RunResult {
+	res := make([]RunResult, len(targets))
+	var mu sync.Mutex
+	parallel(len(targets), concurrency, func(i int) {
+		r := RunResult{Host: targets[i].Name, ExitCode: -1}
+		start := time.Now()
+		defer func() {
+			r.Duration = time.Since(start)
+			res[i] = r
+		}()
+
+		client, err := Connect(targets[i], o)
+		if err != nil {
+			r.Error = err.Error()
+			return
+		}
+		defer client.Close()
+
+		var outB, errB bytes.Buffer
+		var stdout, stderr io.Writer = &outB, &errB
+		if output != nil {
+			outW := &lineWriter{host: r.Host, handler: output, mu: &mu}
+			errW := &lineWriter{host: r.Host, stderr: true, handler: output, mu: &mu}
+			defer outW.flush()
+			defer errW.flush()
+			stdout = io.MultiWriter(&outB, outW)
+			stderr = io.MultiWriter(&errB, errW)
+		}
+		r.ExitCode, err = runWith(client, command, stdout, stderr)
+		if err != nil {
+			r.Error = err.Error()
+		}
+		r.Stdout = outB.String()
+		r.Stderr = errB.String()
+	})
+	return res
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekara-platform/engine/ssh/sshtest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRunAll(t *testing.T) {
+	signer := testSigner(t)
+	s, err := sshtest.NewServer([]ssh.PublicKey{signer.PublicKey()}, echoHandler)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	targets := []Target{
+		{Name: "host1", Address: s.Address(), Port: s.Port(), User: "admin"},
+		{Name: "host2", Address: s.Address(), Port: s.Port()},
+		{Name: "host3", Address: "127.0.0.1", Port: unusedPort(t)},
+	}
+	lines := []string{}
+	res := RunAll(targets, ConnectOptions{Signer: signer, InsecureIgnoreHostKey: true, User: "ekara", Timeout: 2 * time.Second}, "uptime", 2, func(host string, line string, stderr bool) {
+		lines = append(lines, host+":"+line)
+	})
+	sort.Strings(lines)
+	assert.Equal(t, []string{"host1:admin:uptime", "host2:ekara:uptime"}, lines)
+	if assert.Len(t, res, 3) {
+		assert.Equal(t, "host1", res[0].Host)
+		assert.Equal(t, 0, res[0].ExitCode)
+		assert.Equal(t, "admin:uptime", res[0].Stdout)
+		assert.Equal(t, "ekara:uptime", res[1].Stdout)
+		assert.Equal(t, -1, res[2].ExitCode)
+		assert.Contains(t, res[2].Error, "unable to reach")
+	}
+
+	res = RunAll(targets[:1], ConnectOptions{Signer: signer, InsecureIgnoreHostKey: true}, "fail now", 0, nil)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 3, res[0].ExitCode)
+		assert.Equal(t, "failed", res[0].Stderr)
+		assert.Equal(t, "", res[0].Error)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	lines := []string{}
+	w := &lineWriter{host: "host1", stderr: true, mu: &sync.Mutex{}, handler: func(host string, line string, stderr bool) {
+		assert.Equal(t, "host1", host)
+		assert.True(t, stderr)
+		lines = append(lines, line)
+	}}
+	w.Write([]byte("first"))
+	w.Write([]byte(" line\nsecond line\nlast"))
+	assert.Equal(t, []string{"first line", "second line"}, lines)
+	w.flush()
+	assert.Equal(t, []string{"first line", "second line", "last"}, lines)
+}
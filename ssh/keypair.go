@@ -0,0 +1,244 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/ssh"
+)
+
+//KeyType is the type of an SSH key
+type KeyType string
+
+const (
+	//RSA keys, 4096 bits by default
+	RSA KeyType = "rsa"
+	//ECDSA keys, on the P-256 curve by default
+	ECDSA KeyType = "ecdsa"
+	//Ed25519 keys
+	Ed25519 KeyType = "ed25519"
+
+	//DefaultKeyType is the type of the generated keys unless specified
+	DefaultKeyType = Ed25519
+	//MinRSABits is the minimum size of the RSA keys considered as valid
+	MinRSABits = 2048
+
+	defaultRSABits   = 4096
+	defaultECDSABits = 256
+)
+
+type (
+	//KeyOptions specifies the keys to generate
+	KeyOptions struct {
+		// Type is the type of the key, DefaultKeyType if empty
+		Type KeyType
+		// Bits is the size of the RSA keys or the curve of the ECDSA keys (256, 384 or 521)
+		Bits int
+		// Passphrase encrypts the private key if not empty
+		Passphrase []byte
+		// Comment is the comment of the key, usually identifying its owner
+		Comment string
+	}
+
+	//KeyPair holds an SSH key pair
+	KeyPair struct {
+		// Public is the public key, in the authorized_keys format
+		Public []byte
+		// Private is the private key, in the OpenSSH format for the generated keys
+		Private []byte
+		// Fingerprint is the SHA256 fingerprint of the public key
+		Fingerprint string
+		// signer gives access to the private key
+		signer ssh.Signer
+	}
+
+	//Rotation holds the key pairs accepted while the nodes are switching from
+	//the previous key pair to the current one
+	Rotation struct {
+		// Previous is the key pair being replaced
+		Previous KeyPair
+		// Current is the new key pair
+		Current KeyPair
+	}
+)
+
+//GenerateKeyPair creates a new key pair, the private key being encoded in the
+//OpenSSH format
+func GenerateKeyPair(opts KeyOptions) (KeyPair, error) {
+	res := KeyPair{}
+	key, err := generateKey(opts)
+	if err != nil {
+		return res, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return res, err
+	}
+	res.Private, err = encodePrivateKeyToOpenSSH(key, opts.Comment, opts.Passphrase)
+	if err != nil {
+		return res, err
+	}
+	res.signer = signer
+	res.Public = ssh.MarshalAuthorizedKey(signer.PublicKey())
+	res.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+	return res, nil
+}
+
+func generateKey(opts KeyOptions) (crypto.PrivateKey, error) {
+	switch opts.Type {
+	case Ed25519, "":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case ECDSA:
+		var curve elliptic.Curve
+		switch opts.Bits {
+		case 0, defaultECDSABits:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA key size %d, 256, 384 or 521 is expected", opts.Bits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case RSA:
+		bits := opts.Bits
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits < MinRSABits {
+			return nil, fmt.Errorf("the RSA keys must have %d bits at least", MinRSABits)
+		}
+		return generatePrivateKey(bits)
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", opts.Type)
+}
+
+//LoadKeyPair parses and validates an existing key pair.
+//
+//The private key can be encoded in the OpenSSH or PEM formats and is decrypted
+//with the passphrase if it's encrypted. The public key, if given, must match
+//the private one, otherwise it's derived from the private key.
+func LoadKeyPair(public []byte, private []byte, passphrase []byte) (KeyPair, error) {
+	res := KeyPair{Private: private}
+	var key interface{}
+	var err error
+	if len(passphrase) > 0 {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(private, passphrase)
+	} else {
+		key, err = ssh.ParseRawPrivateKey(private)
+	}
+	if err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return res, errors.New("the private key is encrypted, a passphrase is required")
+		}
+		return res, fmt.Errorf("invalid private key: %s", err.Error())
+	}
+	if err := validateKey(key); err != nil {
+		return res, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return res, fmt.Errorf("invalid private key: %s", err.Error())
+	}
+
+	if len(bytes.TrimSpace(public)) > 0 {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(public)
+		if err != nil {
+			return res, fmt.Errorf("invalid public key: %s", err.Error())
+		}
+		if !bytes.Equal(pub.Marshal(), signer.PublicKey().Marshal()) {
+			return res, fmt.Errorf("the public key %s doesn't match the private key %s", ssh.FingerprintSHA256(pub), ssh.FingerprintSHA256(signer.PublicKey()))
+		}
+		res.Public = public
+	} else {
+		res.Public = ssh.MarshalAuthorizedKey(signer.PublicKey())
+	}
+	res.signer = signer
+	res.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+	return res, nil
+}
+
+//LoadKeyPairFiles parses and validates an existing key pair from its files, the
+//public key file being optional
+func LoadKeyPairFiles(publicPath string, privatePath string, passphrase []byte) (KeyPair, error) {
+	private, err := ioutil.ReadFile(privatePath)
+	if err != nil {
+		return KeyPair{}, err
+	}
+	var public []byte
+	if publicPath != "" {
+		public, err = ioutil.ReadFile(publicPath)
+		if err != nil {
+			return KeyPair{}, err
+		}
+	}
+	return LoadKeyPair(public, private, passphrase)
+}
+
+// validateKey rejects the keys considered as too weak
+func validateKey(key interface{}) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if err := k.Validate(); err != nil {
+			return fmt.Errorf("invalid RSA private key: %s", err.Error())
+		}
+		if k.N.BitLen() < MinRSABits {
+			return fmt.Errorf("the RSA key is too small (%d bits), %d bits at least are required", k.N.BitLen(), MinRSABits)
+		}
+	case *ecdsa.PrivateKey, *ed25519.PrivateKey, ed25519.PrivateKey:
+	default:
+		return fmt.Errorf("unsupported private key type %T", key)
+	}
+	return nil
+}
+
+//Signer returns the signer authenticating with the private key
+func (k KeyPair) Signer() (ssh.Signer, error) {
+	if k.signer == nil {
+		return nil, errors.New("the key pair has not been generated or loaded")
+	}
+	return k.signer, nil
+}
+
+//Fingerprint returns the SHA256 fingerprint of a public key in the authorized_keys format
+func Fingerprint(public []byte) (string, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(public)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %s", err.Error())
+	}
+	return ssh.FingerprintSHA256(pub), nil
+}
+
+//Rotate generates a new key pair replacing the given one.
+//
+//Both key pairs are returned so the new public key can be authorized on the
+//nodes, along with the previous one, before switching to the new private key.
+func Rotate(previous KeyPair, opts KeyOptions) (Rotation, error) {
+	current, err := GenerateKeyPair(opts)
+	if err != nil {
+		return Rotation{}, err
+	}
+	return Rotation{Previous: previous, Current: current}, nil
+}
+
+//AuthorizedKeys returns the public keys accepted during the transition period
+func (r Rotation) AuthorizedKeys() []byte {
+	res := make([]byte, 0, len(r.Current.Public)+len(r.Previous.Public)+1)
+	res = append(res, bytes.TrimSpace(r.Current.Public)...)
+	res = append(res, '\n')
+	if len(bytes.TrimSpace(r.Previous.Public)) > 0 {
+		res = append(res, bytes.TrimSpace(r.Previous.Public)...)
+		res = append(res, '\n')
+	}
+	return res
+}
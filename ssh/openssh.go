@@ -0,0 +1,153 @@
+package ssh
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	openSSHMagic     = "openssh-key-v1\x00"
+	openSSHPEMType   = "OPENSSH PRIVATE KEY"
+	openSSHCipher    = "aes256-ctr"
+	openSSHKdf       = "bcrypt"
+	openSSHKdfRounds = 16
+	openSSHSaltSize  = 16
+)
+
+// encodePrivateKeyToOpenSSH encodes the private key in the OpenSSH format,
+// encrypted if a passphrase is given.
+//
+// See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+func encodePrivateKeyToOpenSSH(key crypto.PrivateKey, comment string, passphrase []byte) ([]byte, error) {
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := openSSHKeyFields(key)
+	if err != nil {
+		return nil, err
+	}
+
+	w := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName: "none",
+		KdfName:    "none",
+		NumKeys:    1,
+		PubKey:     signer.PublicKey().Marshal(),
+	}
+
+	blockSize := 8
+	var stream cipher.Stream
+	if len(passphrase) > 0 {
+		salt := make([]byte, openSSHSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		k, err := bcryptPbkdf(passphrase, salt, openSSHKdfRounds, 32+aes.BlockSize)
+		if err != nil {
+			return nil, err
+		}
+		c, err := aes.NewCipher(k[:32])
+		if err != nil {
+			return nil, err
+		}
+		stream = cipher.NewCTR(c, k[32:])
+		blockSize = aes.BlockSize
+		w.CipherName = openSSHCipher
+		w.KdfName = openSSHKdf
+		w.KdfOpts = string(ssh.Marshal(struct {
+			Salt   string
+			Rounds uint32
+		}{string(salt), openSSHKdfRounds}))
+	}
+
+	// The check bytes allow to detect a wrong passphrase
+	check := make([]byte, 4)
+	if _, err := rand.Read(check); err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check)
+	block := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+	}{checkInt, checkInt, signer.PublicKey().Type()})
+	block = append(block, fields...)
+	block = append(block, ssh.Marshal(struct{ Comment string }{comment})...)
+	for i := 1; len(block)%blockSize != 0; i++ {
+		block = append(block, byte(i))
+	}
+	if stream != nil {
+		stream.XORKeyStream(block, block)
+	}
+	w.PrivKeyBlock = block
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  openSSHPEMType,
+		Bytes: append([]byte(openSSHMagic), ssh.Marshal(w)...),
+	}), nil
+}
+
+// openSSHKeyFields returns the fields specific to the type of the private key
+func openSSHKeyFields(key crypto.PrivateKey) ([]byte, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return ssh.Marshal(struct {
+			Pub  []byte
+			Priv []byte
+		}{[]byte(k.Public().(ed25519.PublicKey)), []byte(k)}), nil
+	case *ecdsa.PrivateKey:
+		curve, err := curveName(k.Curve)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.Marshal(struct {
+			Curve string
+			Pub   []byte
+			D     *big.Int
+		}{curve, elliptic.Marshal(k.Curve, k.X, k.Y), k.D}), nil
+	case *rsa.PrivateKey:
+		k.Precompute()
+		return ssh.Marshal(struct {
+			N    *big.Int
+			E    *big.Int
+			D    *big.Int
+			Iqmp *big.Int
+			P    *big.Int
+			Q    *big.Int
+		}{k.N, big.NewInt(int64(k.E)), k.D, k.Precomputed.Qinv, k.Primes[0], k.Primes[1]}), nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// curveName returns the OpenSSH name of the elliptic curve
+func curveName(c elliptic.Curve) (string, error) {
+	switch c {
+	case elliptic.P256():
+		return "nistp256", nil
+	case elliptic.P384():
+		return "nistp384", nil
+	case elliptic.P521():
+		return "nistp521", nil
+	}
+	return "", fmt.Errorf("unsupported elliptic curve %s", c.Params().Name)
+}
@@ -0,0 +1,185 @@
+//Package sshtest provides an in-process SSH server for testing purposes
+package sshtest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type (
+	//CommandHandler answers a command run on a Server
+	CommandHandler func(user string, command string) (stdout string, stderr string, code int)
+
+	//Server is an in-process SSH server, accepting only the authorized keys
+	//and answering the commands through a handler.
+	//
+	//The server can also be used as a bastion, forwarding the connections to
+	//other addresses.
+	Server struct {
+		listener net.Listener
+		config   *ssh.ServerConfig
+		handler  CommandHandler
+		wg       sync.WaitGroup
+		// HostKey is the public key of the server
+		HostKey ssh.PublicKey
+	}
+)
+
+//NewServer starts a server listening on the loopback interface
+func NewServer(authorized []ssh.PublicKey, handler CommandHandler) (*Server, error) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, a := range authorized {
+				if bytes.Equal(a.Marshal(), key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New("unauthorized key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: l,
+		config:   config,
+		handler:  handler,
+		HostKey:  hostSigner.PublicKey(),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+//Address returns the address the server listens on
+func (s *Server) Address() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+//Port returns the port the server listens on
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+//HostPort returns the address and port the server listens on
+func (s *Server) HostPort() string {
+	return net.JoinHostPort(s.Address(), strconv.Itoa(s.Port()))
+}
+
+//Close stops the server
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sConn.Close()
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		switch nc.ChannelType() {
+		case "session":
+			ch, requests, err := nc.Accept()
+			if err != nil {
+				continue
+			}
+			go s.handleSession(sConn.User(), ch, requests)
+		case "direct-tcpip":
+			go s.handleForward(nc)
+		default:
+			nc.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *Server) handleForward(nc ssh.NewChannel) {
+	var payload struct {
+		DestAddr string
+		DestPort uint32
+		OrigAddr string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(nc.ExtraData(), &payload); err != nil {
+		nc.Reject(ssh.Prohibited, "invalid forward request")
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(payload.DestAddr, strconv.Itoa(int(payload.DestPort))))
+	if err != nil {
+		nc.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, requests, err := nc.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(ch, conn)
+		ch.CloseWrite()
+	}()
+	io.Copy(conn, ch)
+	conn.Close()
+	ch.Close()
+}
+
+func (s *Server) handleSession(user string, ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+
+		stdout, stderr, code := "", "", 0
+		if s.handler != nil {
+			stdout, stderr, code = s.handler(user, payload.Command)
+		}
+		ch.Write([]byte(stdout))
+		ch.Stderr().Write([]byte(stderr))
+		status := struct{ Status uint32 }{uint32(code)}
+		ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+		return
+	}
+}
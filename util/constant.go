@@ -50,6 +50,9 @@ const (
 	//OutputYamlFileName is the name of any file containing params
 	OutputYamlFileName string = "output.yaml"
 
+	//DiffFileName is the name of the file holding the changes reported by a playbook launched in check mode
+	DiffFileName string = "diff.txt"
+
 	//ExtraVarYamlFileName is the name of any file containing extra vars
 	ExtraVarYamlFileName string = "extra-vars.yaml"
 
@@ -64,4 +67,23 @@ const (
 
 	//InventoryModuleFolder is the name of any folder containing inventories
 	InventoryModuleFolder string = "inventory"
+
+	//ComponentRolesFolder is the name of any folder containing shared roles
+	ComponentRolesFolder string = "roles"
+
+	//ComponentCollectionsFolder is the name of any folder containing ansible collections
+	ComponentCollectionsFolder string = "collections"
+
+	//ComponentFilterPluginsFolder is the name of any folder containing filter plugins
+	ComponentFilterPluginsFolder string = "filter_plugins"
+
+	//ComponentLookupPluginsFolder is the name of any folder containing lookup plugins
+	ComponentLookupPluginsFolder string = "lookup_plugins"
+
+	//ComponentCallbackPluginsFolder is the name of any folder containing callback plugins
+	ComponentCallbackPluginsFolder string = "callback_plugins"
+
+	//ComponentLibraryFolder is the name of any folder containing ansible modules,
+	//completing the ones of the modules folders
+	ComponentLibraryFolder string = "library"
 )
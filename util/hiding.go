@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ekara-platform/engine/secret"
+)
+
+type (
+	// hidingLaunchContext is a launch context never disclosing the sensitive
+	// values into its logs and its feedback
+	hidingLaunchContext struct {
+		LaunchContext
+		logger *log.Logger
+		fN     FeedbackNotifier
+	}
+
+	// hidingFeedbackNotifier is a feedback notifier hiding the sensitive values
+	hidingFeedbackNotifier struct {
+		fN FeedbackNotifier
+	}
+)
+
+//HidingLaunchContext returns a launch context hiding the sensitive values
+//written into the logs and the feedback of the given one.
+//
+//The sensitive values are the ones marked through the secret package.
+func HidingLaunchContext(lC LaunchContext) LaunchContext {
+	if _, ok := lC.(hidingLaunchContext); ok {
+		return lC
+	}
+	l := lC.Log()
+	return hidingLaunchContext{
+		LaunchContext: lC,
+		logger:        log.New(secret.HidingWriter(l.Writer()), l.Prefix(), l.Flags()),
+		fN:            hidingFeedbackNotifier{fN: lC.Feedback()},
+	}
+}
+
+func (lC hidingLaunchContext) Log() *log.Logger {
+	return lC.logger
+}
+
+func (lC hidingLaunchContext) Feedback() FeedbackNotifier {
+	return lC.fN
+}
+
+func (r hidingFeedbackNotifier) Info(message string, v ...interface{}) {
+	r.fN.Info("%s", secret.Hide(fmt.Sprintf(message, v...)))
+}
+
+func (r hidingFeedbackNotifier) Error(message string, v ...interface{}) {
+	r.fN.Error("%s", secret.Hide(fmt.Sprintf(message, v...)))
+}
+
+func (r hidingFeedbackNotifier) Progress(key string, message string, v ...interface{}) {
+	r.fN.Progress(key, "%s", secret.Hide(fmt.Sprintf(message, v...)))
+}
+
+func (r hidingFeedbackNotifier) ProgressG(key string, goal int, message string, v ...interface{}) {
+	r.fN.ProgressG(key, goal, "%s", secret.Hide(fmt.Sprintf(message, v...)))
+}
+
+func (r hidingFeedbackNotifier) Detail(message string, v ...interface{}) {
+	r.fN.Detail("%s", secret.Hide(fmt.Sprintf(message, v...)))
+}
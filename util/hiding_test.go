@@ -0,0 +1,32 @@
+package util
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/ekara-platform/engine/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHidingLaunchContext(t *testing.T) {
+	secret.MarkSensitive("hidden-value")
+
+	var b bytes.Buffer
+	logger := log.New(&b, "", 0)
+	lC := HidingLaunchContext(&MockLaunchContext{logger: logger, fN: CreateLoggingProgressNotifier(logger)})
+
+	lC.Log().Printf("log with %s", "hidden-value")
+	lC.Feedback().Info("info with %s", "hidden-value")
+	lC.Feedback().Error("error with hidden-value")
+	lC.Feedback().Progress("key", "progress with %s", "hidden-value")
+	lC.Feedback().ProgressG("key", 2, "progress with %s", "hidden-value")
+	lC.Feedback().Detail("detail with %s", "hidden-value")
+
+	assert.NotContains(t, b.String(), "hidden-value")
+	assert.Contains(t, b.String(), "log with ******")
+	assert.Contains(t, b.String(), "detail with ******")
+
+	// Wrapping twice has no effect
+	assert.Equal(t, lC, HidingLaunchContext(lC))
+}
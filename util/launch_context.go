@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/ekara-platform/engine/model"
 	"log"
+	"time"
 )
 
 type (
@@ -24,7 +25,51 @@ type (
 		SSHPublicKey() string
 		//SSHPrivateKey the private key used by the engine during the process execution to connect the created nodes
 		SSHPrivateKey() string
+		//SSHKnownHostsFile returns the location of the host keys accepted while connecting the nodes, the one of the user if empty
+		SSHKnownHostsFile() string
+		//SSHInsecureHostKeys tells if any host key is accepted while connecting the nodes, instead of checking the known hosts
+		SSHInsecureHostKeys() bool
 		//ParamsFile returns the content the parameters provided by the user to fill the environment descriptor as a template
 		ExternalVars() model.Parameters
+		//TemplateEnvAllowlist returns the environment variables readable by the templates, a name ending with "*" allows a prefix
+		TemplateEnvAllowlist() []string
+		//LenientTemplating tells if missing keys are tolerated while templating the descriptors, instead of failing
+		LenientTemplating() bool
+		//SecretKeyFile returns the location of the key used to decrypt the secrets, if any
+		SecretKeyFile() string
+		//VirtualenvDir returns the directory holding the python virtualenvs used to run ansible, if customized
+		VirtualenvDir() string
+		//CheckMode tells if the playbooks must only report the changes they would make, without applying them
+		CheckMode() bool
+		//PlayOptions returns the options used to launch all the playbooks, overriding the ones of the descriptor
+		PlayOptions() model.PlayOptions
+		//InventoryTimeout returns the maximum duration of the inventory generation, if customized
+		InventoryTimeout() time.Duration
+		//FactCacheTTL returns how long the facts gathered on the hosts are kept across executions,
+		//they are only shared within an execution if zero
+		FactCacheTTL() time.Duration
+		//ExecOptions returns the command run by the EXEC action and the hosts it targets
+		ExecOptions() ExecOptions
+		//PolicyRulesFile returns the location of the declarative policy rules the environment must comply with, if any
+		PolicyRulesFile() string
+		//PolicyRules returns the policy rules, registered as Go functions, the environment must comply with, if any
+		PolicyRules() []model.PolicyRule
+	}
+
+	//ExecOptions specifies the command run by the EXEC action and the hosts it targets.
+	//
+	//The targeted hosts must match all the given criteria, all the hosts are
+	//targeted without any.
+	ExecOptions struct {
+		// The command to run
+		Command string
+		// The node set holding the targeted hosts
+		NodeSet string
+		// The label selector of the targeted hosts
+		Labels string
+		// The inventory group holding the targeted hosts
+		Group string
+		// The number of hosts processed in parallel
+		Concurrency int
 	}
 )
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 )
 
 type (
@@ -87,7 +88,72 @@ func (lC MockLaunchContext) SSHPrivateKey() string {
 	return lC.sshPrivateKeyContent
 }
 
+//SSHKnownHostsFile simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) SSHKnownHostsFile() string {
+	return ""
+}
+
+//SSHInsecureHostKeys simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) SSHInsecureHostKeys() bool {
+	return false
+}
+
 //ParamsFile simulates the corresponding method in LaunchContext for testing purposes
 func (lC MockLaunchContext) ExternalVars() model.Parameters {
 	return lC.externalVars
 }
+
+//TemplateEnvAllowlist simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) TemplateEnvAllowlist() []string {
+	return nil
+}
+
+//LenientTemplating simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) LenientTemplating() bool {
+	return false
+}
+
+//SecretKeyFile simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) SecretKeyFile() string {
+	return ""
+}
+
+//VirtualenvDir simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) VirtualenvDir() string {
+	return ""
+}
+
+//CheckMode simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) CheckMode() bool {
+	return false
+}
+
+//PlayOptions simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) PlayOptions() model.PlayOptions {
+	return model.PlayOptions{}
+}
+
+//InventoryTimeout simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) InventoryTimeout() time.Duration {
+	return 0
+}
+
+//FactCacheTTL simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) FactCacheTTL() time.Duration {
+	return 0
+}
+
+//ExecOptions simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) ExecOptions() ExecOptions {
+	return ExecOptions{}
+}
+
+//PolicyRulesFile simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) PolicyRulesFile() string {
+	return ""
+}
+
+//PolicyRules simulates the corresponding method in LaunchContext for testing purposes
+func (lC MockLaunchContext) PolicyRules() []model.PolicyRule {
+	return nil
+}
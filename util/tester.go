@@ -24,6 +24,9 @@ func CreateComponentTester(t *testing.T, extVars model.Parameters) EkaraComponen
 
 func (t EkaraComponentTester) Init(repo componentizer.Repository) {
 	err := t.ComponentTester.Init(model.CreateComponent(model.MainComponentId, repo))
+	if err == nil {
+		err = t.ComponentTester.TemplateContext().(*model.TemplateContext).CheckDescriptors()
+	}
 	if err != nil {
 		assert.Nil(t.T(), err, "Init error: %s", err.Error())
 	}